@@ -0,0 +1,17 @@
+{{template "base" .}}
+{{define "preheader"}}账号注销提醒{{end}}
+{{define "content"}}
+    <tr>
+        <td class="wrapper">
+            <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                <tr>
+                    <td>
+                        <p>👋&nbsp; 你好~ {{.UserName}} ~ </p>
+                        <p>🗑&nbsp; 您的账户已申请注销，将于 {{.DeleteAt}} 删除。删除后您的个人信息、点赞和表情回应将被清除，评论和留言将显示为已注销用户。</p>
+                        <p>↩️&nbsp; 在此之前您可以登录后在个人中心取消注销。如果这不是您本人的操作，请尽快取消注销并修改密码。</p>
+                    </td>
+                </tr>
+            </table>
+        </td>
+    </tr>
+{{end}}
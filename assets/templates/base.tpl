@@ -25,7 +25,7 @@
                 </div>
                 <div class="content">
                     <!-- START CENTERED WHITE CONTAINER -->
-                    <span class="preheader">感谢使用我们的服务，仅差一步激活邮箱啦</span>
+                    <span class="preheader">{{block "preheader" .}}感谢使用我们的服务，仅差一步激活邮箱啦{{end}}</span>
                     <table role="presentation" class="main">
 
                         <!-- START MAIN CONTENT AREA -->
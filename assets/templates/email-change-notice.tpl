@@ -0,0 +1,17 @@
+{{template "base" .}}
+{{define "preheader"}}邮箱修改提醒{{end}}
+{{define "content"}}
+    <tr>
+        <td class="wrapper">
+            <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                <tr>
+                    <td>
+                        <p>👋&nbsp; 你好~ {{.UserName}} ~ </p>
+                        <p>📬&nbsp; 您的账户申请将邮箱修改为 {{.Email}}，新邮箱确认后，本邮箱将不能再用于登录和接收通知。</p>
+                        <p>🛡&nbsp; 如果这不是您本人的操作，您的账户可能已被他人登录，请尽快修改密码。</p>
+                    </td>
+                </tr>
+            </table>
+        </td>
+    </tr>
+{{end}}
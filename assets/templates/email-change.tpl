@@ -0,0 +1,34 @@
+{{template "base" .}}
+{{define "preheader"}}确认修改邮箱{{end}}
+{{define "content"}}
+    <tr>
+        <td class="wrapper">
+            <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                <tr>
+                    <td>
+                        <p>👋&nbsp; 你好~ {{.UserName}} ~ </p>
+                        <p>📬&nbsp; 您正在将账户邮箱修改为 {{.Email}}，点击以下按钮确认修改。</p>
+                        <table role="presentation" border="0" cellpadding="0" cellspacing="0" class="btn btn-primary">
+                            <tbody>
+                            <tr>
+                                <td align="center">
+                                    <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                                        <tbody>
+                                        <tr>
+                                            <td><a href="{{.URL}}" target="_blank">确认修改</a></td>
+                                        </tr>
+                                        </tbody>
+                                    </table>
+                                </td>
+                            </tr>
+                            </tbody>
+                        </table>
+                        <p>⏰&nbsp; 链接 {{.Expire}} 分钟内有效，且只能使用一次。确认后请使用新邮箱登录。</p>
+                        <p>💃&nbsp; 按钮没反应？尝试将此 URL 粘贴到您的浏览器中：<a class='long-url'>{{.URL}}</a></p>
+                        <p>🛡&nbsp; 如果这不是您本人的操作，请忽略此邮件，邮箱不会被修改。</p>
+                    </td>
+                </tr>
+            </table>
+        </td>
+    </tr>
+{{end}}
@@ -0,0 +1,33 @@
+{{template "base" .}}
+{{define "preheader"}}{{.Nickname}} 提到了你{{end}}
+{{define "content"}}
+    <tr>
+        <td class="wrapper">
+            <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                <tr>
+                    <td>
+                        <p>👋&nbsp; 你好~ {{.UserName}} ~ </p>
+                        <p>💬&nbsp; {{.Nickname}} 在《{{.Title}}》中提到了你：</p>
+                        <p class="quote">{{.Content}}</p>
+                        <table role="presentation" border="0" cellpadding="0" cellspacing="0" class="btn btn-primary">
+                            <tbody>
+                            <tr>
+                                <td align="center">
+                                    <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                                        <tbody>
+                                        <tr>
+                                            <td><a href="{{.URL}}" target="_blank">查看详情</a></td>
+                                        </tr>
+                                        </tbody>
+                                    </table>
+                                </td>
+                            </tr>
+                            </tbody>
+                        </table>
+                        <p>🔕&nbsp; 不想再收到 @ 提及通知？<a href="{{.UnsubscribeURL}}" target="_blank">一键退订</a></p>
+                    </td>
+                </tr>
+            </table>
+        </td>
+    </tr>
+{{end}}
@@ -0,0 +1,19 @@
+{{template "base" .}}
+{{define "preheader"}}{{.Title}}{{end}}
+{{define "content"}}
+    <tr>
+        <td class="wrapper">
+            <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                <tr>
+                    <td>
+                        <p>👋&nbsp; 你好~ {{.UserName}} ~ </p>
+                        <p>📋&nbsp; {{.Title}}：</p>
+                        <p class="quote">{{.Content}}</p>
+                        {{if .Reason}}<p>📝&nbsp; 原因：{{.Reason}}</p>{{end}}
+                        <p>如有疑问，请回复此邮件与我们联系。</p>
+                    </td>
+                </tr>
+            </table>
+        </td>
+    </tr>
+{{end}}
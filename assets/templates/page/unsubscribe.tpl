@@ -0,0 +1,61 @@
+<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            background-color: #f4f4f4;
+            display: flex;
+            justify-content: center;
+            align-items: center;
+            height: 100vh;
+            margin: 0;
+        }
+        .container {
+            background-color: #fff;
+            padding: 20px;
+            border-radius: 8px;
+            box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);
+            text-align: center;
+        }
+        h1.success {
+            color: #5cb85c;
+        }
+        h1.fail {
+            color: #d9534f;
+        }
+        p {
+            color: #333;
+        }
+        a {
+            color: #3498db;
+        }
+        button {
+            padding: 8px 24px;
+            border: none;
+            border-radius: 4px;
+            background-color: #d9534f;
+            color: #fff;
+            cursor: pointer;
+        }
+    </style>
+</head>
+<body>
+<div class="container">
+    {{if .Confirm}}
+    <h1>{{.Title}}</h1>
+    <p>{{.Message}}</p>
+    <form method="post" action="{{.Action}}">
+        <button type="submit">确认退订</button>
+    </form>
+    {{else}}
+    <h1 class="{{if .Success}}success{{else}}fail{{end}}">{{.Title}}</h1>
+    <p>{{.Message}}</p>
+    {{end}}
+    <p><a href="{{.HomeURL}}">返回首页</a></p>
+</div>
+</body>
+</html>
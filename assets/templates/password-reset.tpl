@@ -0,0 +1,34 @@
+{{template "base" .}}
+{{define "preheader"}}重置密码{{end}}
+{{define "content"}}
+    <tr>
+        <td class="wrapper">
+            <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                <tr>
+                    <td>
+                        <p>👋&nbsp; 你好~ {{.UserName}} ~ </p>
+                        <p>🔑&nbsp; 我们收到了重置您账户密码的请求，点击以下按钮设置新密码。</p>
+                        <table role="presentation" border="0" cellpadding="0" cellspacing="0" class="btn btn-primary">
+                            <tbody>
+                            <tr>
+                                <td align="center">
+                                    <table role="presentation" border="0" cellpadding="0" cellspacing="0">
+                                        <tbody>
+                                        <tr>
+                                            <td><a href="{{.URL}}" target="_blank">重置密码</a></td>
+                                        </tr>
+                                        </tbody>
+                                    </table>
+                                </td>
+                            </tr>
+                            </tbody>
+                        </table>
+                        <p>⏰&nbsp; 链接 {{.Expire}} 分钟内有效，且只能使用一次。重置后所有已登录的设备都需要重新登录。</p>
+                        <p>💃&nbsp; 按钮没反应？尝试将此 URL 粘贴到您的浏览器中：<a class='long-url'>{{.URL}}</a></p>
+                        <p>🛡&nbsp; 如果这不是您本人的操作，请忽略此邮件，您的密码不会被修改。</p>
+                    </td>
+                </tr>
+            </table>
+        </td>
+    </tr>
+{{end}}
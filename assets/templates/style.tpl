@@ -105,6 +105,12 @@
         text-align: center;
     }
 
+    .quote {
+        border-left: 4px solid #dddddd;
+        color: #555555;
+        padding-left: 12px;
+    }
+
     /* -------------------------------------
         TYPOGRAPHY
     ------------------------------------- */
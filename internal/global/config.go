@@ -21,6 +21,10 @@ type Config struct {
 		DbType        string // 数据库类型
 		DbAutoMigrate bool   // 数据库表结构是否自动迁移
 		DbLogMode     string // 数据库日志模式（silent | error | warn | info）
+		PublicURL     string // 博客对外访问地址(例如 https://blog.example.com), 用于生成邮件中的链接
+		// 信任的反向代理 IP/网段, 只有来自这些地址的请求才通过 X-Forwarded-For/X-Real-IP 获取客户端 IP
+		// 为空时不信任任何代理, 直接使用连接的远程地址
+		TrustedProxies []string
 	}
 	//
 	//  Log
@@ -37,9 +41,10 @@ type Config struct {
 	//	@Description:JWT配置
 	//
 	JWT struct {
-		Secret string // JWT密钥
-		Expire int64  // JWT过期时间(hours)
-		Issuer string // JWT签发者
+		Secret        string // JWT密钥
+		AccessExpire  int64  // 访问令牌过期时间(minutes), 默认 15 分钟
+		RefreshExpire int64  // 刷新令牌过期时间(hours), 默认 168 小时 (7 天)
+		Issuer        string // JWT签发者
 	}
 	//
 	//  Mysql
@@ -90,6 +95,29 @@ type Config struct {
 	Captcha struct {
 		SendEmail  bool //是否发送邮件验证码
 		ExpireTime int  //验证码过期时间(seconds)
+		Level      int  //图片验证码难度: 1. 10 以内加减法 2. 100 以内加减法 (默认) 3. 100 以内加减法和乘法
+	}
+	//
+	//  Login
+	//	@Description:登录防暴力破解配置
+	Login struct {
+		CaptchaAfter int //同一账号或 IP 登录失败多少次后需要图片验证码, 默认 3
+		LockAfter    int //同一账号登录失败多少次后锁定, 默认 10
+		IPLockAfter  int //同一 IP 登录失败多少次后锁定, 默认 50
+		LockTime     int //锁定时间(minutes), 默认 15
+		FailWindow   int //失败次数统计时间窗口(minutes), 每次失败重新计时, 默认 60
+	}
+	//
+	//  Spam
+	//	@Description:垃圾评论/留言检测配置
+	Spam struct {
+		Enable          bool    //是否开启垃圾内容检测
+		ReviewScore     float64 //分数达到该值时进入人工审核(0-1)
+		RejectScore     float64 //分数达到该值时直接拒绝(0-1)
+		MaxLinks        int     //链接数量达到该值时分数为 1
+		RateLimit       int     //时间窗口内同一 IP/用户 最多发布数量
+		RateWindow      int     //发布频率统计时间窗口(seconds)
+		DuplicateWindow int     //重复内容检测时间窗口(seconds)
 	}
 	//
 	//  Upload
@@ -99,6 +127,17 @@ type Config struct {
 		OssType   string //OSS存储类型(local | qiniu)
 		Path      string //本地文件访问路径
 		StorePath string //本地文件存储路径
+
+		PrivatePath string   //私有文件存储路径(不对外静态暴露, 只能通过签名链接访问)
+		SignKey     string   //私有文件签名密钥(为空时使用 JWT 密钥)
+		SignExpire  int      //私有文件签名链接有效期(seconds)
+		Referers    []string //允许引用公开文件的来源域名, "*.example.com" 同时匹配主域名(为空时不做防盗链校验)
+	}
+	//
+	//  OAuth
+	//	@Description:第三方登录配置
+	OAuth struct {
+		Providers map[string]OAuthProvider //第三方登录提供方, key 为提供方名称, 用于登录链接 /api/oauth/:provider/login
 	}
 	//
 	//  Qiniu
@@ -114,6 +153,19 @@ type Config struct {
 	}
 }
 
+// OAuthProvider 第三方登录提供方配置
+type OAuthProvider struct {
+	Type         string   //提供方类型(github | gitee | qq | oidc), 为空时使用提供方名称
+	ClientID     string   //应用 id (QQ 为 APP ID)
+	ClientSecret string   //应用密钥
+	Issuer       string   //OIDC 签发者地址, 通过 {Issuer}/.well-known/openid-configuration 获取各端点, Type="oidc" 生效
+	Scopes       []string //申请的权限, 为空时使用各提供方的默认值
+	RedirectURL  string   //回调地址, 为空时使用 {Server.PublicURL}/api/oauth/{name}/callback
+	AuthURL      string   //授权地址, 为空时使用各提供方的默认值 (用于自建 GitHub/Gitee 或测试)
+	TokenURL     string   //令牌地址, 为空时使用各提供方的默认值
+	APIURL       string   //用户信息接口地址前缀, 为空时使用各提供方的默认值
+}
+
 // Conf 存储应用配置的全局变量
 var Conf *Config
 
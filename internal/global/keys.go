@@ -2,10 +2,11 @@ package global
 
 // Redis key
 const (
-	ONLINE_USER  = "online_user:"  // 在线用户
-	OFFLINE_USER = "offline_user:" // 强制下线用户
-	VISITOR_AREA = "visitor_area"  // 地域统计
-	VIEW_COUNT   = "view_count"    // 访问数量
+	ONLINE_USER   = "online_user:"   // 在线用户
+	OFFLINE_USER  = "offline_user:"  // 强制下线用户
+	REVOKED_TOKEN = "revoked_token:" // 已吊销的访问令牌 (jti)
+	VISITOR_AREA  = "visitor_area"   // 地域统计
+	VIEW_COUNT    = "view_count"     // 访问数量
 
 	KEY_UNIQUE_VISITOR_SET = "unique_visitor" // 唯一用户记录 set
 
@@ -15,6 +16,41 @@ const (
 
 	COMMENT_USER_LIKE_SET = "comment_user_like:" // 评论点赞 Set
 	COMMENT_LIKE_COUNT    = "comment_like_count" // 评论点赞数
+	COMMENT_LIKE_SYNCED   = "comment_like_sync"  // 评论点赞数已同步到数据库 (comment.like_count) 的标记
+
+	REACTION_COUNT  = "reaction_count:" // 表情回应数量 Hash (表情 -> 数量), 每个对象一个
+	REACTION_USER   = "reaction_user:"  // 用户的表情回应 Set (对象id:表情), 每个用户每类对象一个
+	REACTION_LOADED = "reaction_loaded" // 表情回应数据已从数据库加载的标记
+
+	CAPTCHA = "captcha:" // 图片验证码答案
+
+	EMAIL_QUEUE      = "email_queue"       // 邮件发送队列
+	EMAIL_PROCESSING = "email_processing"  // 正在发送的邮件任务, 发送完成后移除
+	COMMENT_NOTIFIED = "comment_notified:" // 已发送过通知的评论, 防止重复通知
+	MESSAGE_NOTIFIED = "message_notified:" // 已发送过通知的留言, 防止重复通知
+	DANMAKU_CHANNEL  = "danmaku"           // 实时弹幕 pub/sub 频道
+
+	EMAIL_VERIFY         = "email_verify:"         // 注册验证令牌 (摘要) => 等待验证的注册信息 (邮箱, 加密后的密码)
+	EMAIL_VERIFY_PENDING = "email_verify_pending:" // 注册邮箱 => 当前有效的验证令牌 (摘要), 重新发送时作废旧链接
+	EMAIL_VERIFY_LIMIT   = "email_verify_limit:"   // 同一邮箱发送注册验证邮件的频率限制
+
+	PASSWORD_RESET       = "password_reset:"       // 重置密码令牌 (摘要) => 用户id
+	PASSWORD_RESET_LIMIT = "password_reset_limit:" // 同一邮箱请求重置密码的频率限制
+	PASSWORD_RESET_USER  = "password_reset_user:"  // 用户id => 未使用的重置密码令牌 (摘要) Set, 修改密码后全部作废
+	EMAIL_CHANGE         = "email_change:"         // 修改邮箱令牌 (摘要) => 用户id, 新邮箱
+	EMAIL_CHANGE_LIMIT   = "email_change_limit:"   // 同一用户请求修改邮箱的频率限制
+
+	TWO_FACTOR_PREAUTH = "two_factor_preauth:" // 两步验证的预认证令牌 (摘要) => 用户id, 尝试次数
+	OAUTH_STATE        = "oauth_state:"        // 第三方登录授权请求 (state) => 提供方、PKCE 参数、登录后跳转地址
+	OAUTH_LOGIN        = "oauth_login:"        // 第三方登录成功后的一次性登录码 (摘要) => 用户id
+
+	LOGIN_FAIL = "login_fail:" // 登录失败次数, 按账号 (user:id 或 name:用户名) 和 IP (ip:地址) 分别统计
+	LOGIN_LOCK = "login_lock:" // 登录冷却/锁定标记, 过期前拒绝该账号或 IP 的登录请求
+
+	SESSION_SEEN = "session_seen:" // 登录会话最近活跃时间的更新间隔限制 (访问令牌 id)
+
+	USER_EXPORT_LIMIT = "user_export_limit:" // 同一用户导出个人数据的频率限制
+	USER_DELETE_LOCK  = "user_delete_lock"   // 删除到期注销账号的任务锁, 多个实例同时只有一个执行
 
 	PAGE   = "page"   // 页面封面
 	CONFIG = "config" // 博客配置
@@ -25,11 +61,22 @@ const (
 	CTX_DB        = "_db_field"
 	CTX_RDB       = "_rdb_field"
 	CTX_USER_AUTH = "_user_auth_field"
+	CTX_TOKEN_ID  = "_token_id_field" // 当前会话的访问令牌 id (jti), 用于吊销会话
+
+	COOKIE_GUEST_ID    = "guest_id"    // 游客标识, 用于游客查看自己未审核的评论/留言
+	COOKIE_OAUTH_STATE = "oauth_state" // 第三方登录授权请求的 state, 回调时校验是同一个浏览器
 )
 
 // 配置项
 const (
 	CONFIG_ARTICLE_COVER     = "article_cover"
 	CONFIG_IS_COMMENT_REVIEW = "is_comment_review"
+	CONFIG_IS_MESSAGE_REVIEW = "is_message_review"
+	CONFIG_COMMENT_EDIT_TIME = "comment_edit_time" // 评论发布后允许作者编辑的时间(分钟), 0 表示不允许编辑
+	CONFIG_IS_GUEST_COMMENT  = "is_guest_comment"  // 是否允许游客 (未登录) 评论与留言
+	CONFIG_MAX_MENTIONS      = "max_mentions"      // 单条评论/留言最多 @ 的用户数量
+	CONFIG_REACTION_EMOJIS   = "reaction_emojis"   // 可用的表情回应, 逗号分隔
+	CONFIG_REPORT_THRESHOLD  = "report_threshold"  // 评论/留言被举报多少次后自动隐藏, 0 表示不自动隐藏
+	CONFIG_DANMAKU_MAX_CONN  = "danmaku_max_conn"  // 同一 IP 的实时弹幕最大连接数, 0 表示不限制
 	CONFIG_ABOUT             = "about"
 )
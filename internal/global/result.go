@@ -76,6 +76,10 @@ var (
 	ErrUserNotExist = RegisterResult(1003, "该用户不存在")
 	ErrOldPassword  = RegisterResult(1010, "旧密码不正确")
 	ErrNewPassword  = RegisterResult(1011, "新密码不能与旧密码相同")
+	ErrDeleteSuper  = RegisterResult(1012, "超级管理员账号不能注销")
+	ErrDeleting     = RegisterResult(1013, "账号已申请注销")
+	ErrNotDeleting  = RegisterResult(1014, "账号未申请注销")
+	ErrExportLimit  = RegisterResult(1015, "导出过于频繁，请稍后再试")
 
 	ErrTokenNotExist    = RegisterResult(1201, "TOKEN 不存在，请重新登陆")
 	ErrTokenRuntime     = RegisterResult(1202, "TOKEN 已过期，请重新登陆")
@@ -85,12 +89,48 @@ var (
 	ErrPermission       = RegisterResult(1206, "权限不足")
 	ErrForceOffline     = RegisterResult(1207, "您已被强制下线")
 	ErrForceOfflineSelf = RegisterResult(1208, "不能强制下线自己")
+	ErrUserDisabled     = RegisterResult(1209, "该账号已被禁用")
+	ErrUserBanned       = RegisterResult(1210, "该账号已被封禁")
+	ErrIPBanned         = RegisterResult(1211, "当前 IP 已被封禁")
+	ErrTokenRevoked     = RegisterResult(1212, "TOKEN 已失效，请重新登陆")
+	ErrRefreshToken     = RegisterResult(1213, "刷新令牌无效或已过期，请重新登陆")
+	ErrRefreshReused    = RegisterResult(1214, "刷新令牌已被使用，为保证账号安全已注销该登录，请重新登陆")
+	ErrTwoFactorCode    = RegisterResult(1215, "两步验证码错误")
+	ErrPreAuthToken     = RegisterResult(1216, "两步验证已过期，请重新登陆")
+	ErrTwoFactorPolicy  = RegisterResult(1217, "当前角色要求开启两步验证")
+	ErrTwoFactorOn      = RegisterResult(1218, "两步验证已开启")
+	ErrTwoFactorOff     = RegisterResult(1219, "两步验证未开启")
+	ErrOAuthProvider    = RegisterResult(1220, "不支持该第三方登录方式")
+	ErrOAuthCode        = RegisterResult(1221, "第三方登录已失效，请重新登陆")
+	ErrCredentials      = RegisterResult(1222, "用户名或密码错误")
+	ErrLoginLocked      = RegisterResult(1223, "登录失败次数过多，请稍后再试")
+	ErrLoginCaptcha     = RegisterResult(1224, "登录失败次数过多，请输入验证码")
+	ErrSessionNotExist  = RegisterResult(1225, "登录会话不存在或已失效")
 
 	ErrFileUpload  = RegisterResult(9100, "文件上传失败")
 	ErrFileReceive = RegisterResult(9101, "文件接收失败")
 	ErrFileOpen    = RegisterResult(9102, "文件打开失败")
 	ErrFileInfo    = RegisterResult(9103, "文件信息获取失败")
 	ErrParseRange  = RegisterResult(9104, "文件头解析失败")
+	ErrFileSign    = RegisterResult(9105, "文件签名无效")
+	ErrFileExpired = RegisterResult(9106, "文件链接已过期")
+	ErrFileReferer = RegisterResult(9107, "禁止盗链访问")
+	ErrFileDelete  = RegisterResult(9108, "文件删除失败")
+
+	ErrCommentNotOwner   = RegisterResult(5001, "只能操作自己的评论")
+	ErrCommentEditExpire = RegisterResult(5002, "评论已超过可编辑时间")
+	ErrCommentDeleted    = RegisterResult(5003, "该评论已删除")
+	ErrGuestDisabled     = RegisterResult(5004, "未开启游客评论, 请登录后再评论")
+	ErrCaptcha           = RegisterResult(5005, "验证码错误或已过期")
+	ErrSpam              = RegisterResult(5006, "内容疑似垃圾信息, 请修改后再试")
+	ErrSensitive         = RegisterResult(5007, "内容包含敏感词, 请修改后再试")
+	ErrMentionLimit      = RegisterResult(5008, "@ 的用户数量超过限制")
+	ErrReactionEmoji     = RegisterResult(5009, "不支持的表情")
+	ErrReportDuplicate   = RegisterResult(5010, "你已经举报过该内容")
+	ErrDanmakuConnLimit  = RegisterResult(5011, "实时弹幕连接数过多, 请稍后再试")
+	ErrCommentTooDeep    = RegisterResult(5012, "评论嵌套层级过深, 无法继续回复")
+	ErrArticleNotExist   = RegisterResult(5013, "该文章不存在")
+	ErrCommentNotExist   = RegisterResult(5014, "该评论不存在")
 
 	ErrTagHasArt  = RegisterResult(4003, "删除失败，标签下存在文章")
 	ErrCateHasArt = RegisterResult(3003, "删除失败，分类下存在文章")
@@ -106,4 +146,9 @@ var (
 	ErrCodeNoexit     = RegisterResult(6102, "Code不存在 请重新注册")
 	ErrParseEmailCode = RegisterResult(6103, "解析邮件Code失败 请重试")
 	ErrUserExist      = RegisterResult(6104, "该邮箱已经注册 请重新注册")
+	ErrResetToken     = RegisterResult(6105, "重置密码链接无效或已过期")
+	ErrEmailToken     = RegisterResult(6106, "修改邮箱链接无效或已过期")
+	ErrEmailSame      = RegisterResult(6107, "新邮箱与当前邮箱相同")
+	ErrEmailTaken     = RegisterResult(6108, "该邮箱已被其他账号使用")
+	ErrEmailFrequent  = RegisterResult(6109, "邮件发送过于频繁，请稍后再试")
 )
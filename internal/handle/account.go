@@ -0,0 +1,290 @@
+package handle
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+个人数据导出与注销账号:
+  - 导出: 当前用户的账号信息、评论、留言、点赞、表情回应和上传的文件记录, 打包为 ZIP (每类数据一个 JSON 文件)
+  - 注销: 申请后进入冷静期 (accountDeleteGrace), 冷静期内可以取消; 到期后由后台任务 (RunAccountDeletion) 删除
+  - 删除时评论和留言匿名化保留, 点赞和表情回应从 Redis 中撤销 (计数同时减少), 数据库中的个人数据删除 (见 model.DeleteUserAccount)
+*/
+
+const (
+	accountDeleteGrace    = 7 * 24 * time.Hour // 注销冷静期
+	accountDeleteInterval = time.Hour          // 检查到期注销账号的间隔
+	userExportInterval    = time.Minute        // 同一用户两次导出之间的最短间隔
+)
+
+// likeExport 导出的点赞和表情回应
+type likeExport struct {
+	Articles  []int            `json:"articles"` // 点赞的文章 id
+	Comments  []int            `json:"comments"` // 点赞的评论 id
+	Reactions []model.Reaction `json:"reactions"`
+}
+
+// ExportData 导出当前用户的个人数据, 返回 ZIP 压缩包
+func (*User) ExportData(c *gin.Context) {
+	db, rdb := GetDB(c), GetRDB(c)
+	auth, _ := CurrentUserAuth(c)
+
+	first, err := rdb.SetNX(rctx, global.USER_EXPORT_LIMIT+strconv.Itoa(auth.ID), true, userExportInterval).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	if !first {
+		ReturnError(c, global.ErrExportLimit, nil)
+		return
+	}
+
+	data, err := model.GetUserExport(db, auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	likes := likeExport{Reactions: data.Reactions}
+	articleLikes, err := rdb.SMembers(rctx, global.ARTICLE_USER_LIKE_SET+strconv.Itoa(auth.ID)).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	commentLikes, err := rdb.SMembers(rctx, global.COMMENT_USER_LIKE_SET+strconv.Itoa(auth.ID)).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	likes.Articles, likes.Comments = memberIds(articleLikes), memberIds(commentLikes)
+
+	archive, err := exportArchive(map[string]any{
+		"account.json":  data.Account,
+		"comments.json": data.Comments,
+		"messages.json": data.Messages,
+		"likes.json":    likes,
+		"uploads.json":  data.Uploads,
+	})
+	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	slog.Info("用户导出个人数据", "user", auth.ID)
+	filename := fmt.Sprintf("user-data-%d-%s.zip", auth.ID, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// exportArchive 将每个文件的数据序列化为 JSON 后打包为 ZIP
+func exportArchive(files map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// memberIds 将 Redis Set 中保存的 id 转为整数, 忽略无法解析的成员
+func memberIds(members []string) []int {
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.Atoi(m); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type DeleteAccountReq struct {
+	Password string `json:"password"` // 当前密码, 第三方登录创建的账号不需要
+}
+
+type DeletionVO struct {
+	DeleteAt time.Time `json:"delete_at"` // 冷静期结束 (删除账号) 的时间
+}
+
+// RequestDeletion 申请注销当前账号, 冷静期结束后删除
+func (*User) RequestDeletion(c *gin.Context) {
+	var req DeleteAccountReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db, rdb := GetDB(c), GetRDB(c)
+	auth, _ := CurrentUserAuth(c)
+	if auth.IsSuper {
+		ReturnError(c, global.ErrDeleteSuper, nil)
+		return
+	}
+	if auth.LoginType != model.LOGIN_TYPE_OAUTH && !utils.BcryptCheck(req.Password, auth.Password) {
+		ReturnError(c, global.ErrPassword, nil)
+		return
+	}
+
+	deleteAt := time.Now().Add(accountDeleteGrace)
+	ok, err := model.ScheduleUserDeletion(db, auth.ID, deleteAt)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if !ok {
+		ReturnError(c, global.ErrDeleting, nil)
+		return
+	}
+
+	// 邮件提醒, 账号被盗用时用户可以及时取消; 提醒失败不影响注销
+	if email := currentEmail(auth, auth.UserInfo); email != "" {
+		err = utils.EnqueueEmail(rctx, rdb, utils.EmailTask{
+			To:       email,
+			Subject:  "账号注销提醒",
+			Template: "account-delete.tpl",
+			Data: map[string]string{
+				"UserName": auth.UserInfo.Nickname,
+				"DeleteAt": deleteAt.Format("2006-01-02 15:04"),
+			},
+		})
+		if err != nil {
+			slog.Error("注销账号提醒邮件入队失败", "user", auth.ID, "err", err)
+		}
+	}
+	slog.Info("用户申请注销账号", "user", auth.ID, "delete_at", deleteAt)
+	ReturnSuccess(c, DeletionVO{DeleteAt: deleteAt})
+}
+
+// CancelDeletion 冷静期内取消注销当前账号
+func (*User) CancelDeletion(c *gin.Context) {
+	auth, _ := CurrentUserAuth(c)
+	ok, err := model.CancelUserDeletion(GetDB(c), auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if !ok {
+		ReturnError(c, global.ErrNotDeleting, nil)
+		return
+	}
+	slog.Info("用户取消注销账号", "user", auth.ID)
+	ReturnSuccess(c, nil)
+}
+
+// RunAccountDeletion 定期删除冷静期已结束的账号, ctx 取消时退出
+func RunAccountDeletion(ctx context.Context, db *gorm.DB, rdb *redis.Client) {
+	ticker := time.NewTicker(accountDeleteInterval)
+	defer ticker.Stop()
+	for {
+		deleteDueAccounts(db, rdb)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deleteDueAccounts 删除全部到期的账号, 多个实例同时运行时只有拿到锁的实例执行
+func deleteDueAccounts(db *gorm.DB, rdb *redis.Client) {
+	first, err := rdb.SetNX(rctx, global.USER_DELETE_LOCK, true, accountDeleteInterval/2).Result()
+	if err != nil {
+		slog.Error("获取注销账号任务锁失败", "err", err)
+		return
+	}
+	if !first {
+		return
+	}
+
+	ids, err := model.GetDueUserDeletions(db, time.Now())
+	if err != nil {
+		slog.Error("查询到期注销账号失败", "err", err)
+		return
+	}
+	for _, id := range ids {
+		if err := deleteAccount(db, rdb, id); err != nil {
+			slog.Error("删除注销账号失败", "user", id, "err", err)
+		}
+	}
+}
+
+// deleteAccount 删除一个到期的账号: 数据库中匿名化评论/留言并删除个人数据, 确认删除后吊销令牌, 最后撤销 Redis 中的点赞和表情回应
+func deleteAccount(db *gorm.DB, rdb *redis.Client, userId int) error {
+	uid := strconv.Itoa(userId)
+	articleKey, commentKey := global.ARTICLE_USER_LIKE_SET+uid, global.COMMENT_USER_LIKE_SET+uid
+	articleLikes, err := rdb.SMembers(rctx, articleKey).Result()
+	if err != nil {
+		return err
+	}
+	commentLikes, err := rdb.SMembers(rctx, commentKey).Result()
+	if err != nil {
+		return err
+	}
+	reactions := make(map[string][]string)
+	for _, typ := range []string{model.REACTION_ARTICLE, model.REACTION_COMMENT} {
+		if reactions[typ], err = rdb.SMembers(rctx, reactionUserKey(typ, userId)).Result(); err != nil {
+			return err
+		}
+	}
+
+	deleted, jtis, err := model.DeleteUserAccount(db, userId, memberIds(commentLikes))
+	if err != nil {
+		return err
+	}
+	if !deleted { // 用户已取消注销, 令牌保持有效
+		return nil
+	}
+	// 令牌记录已随账号删除, 将已签发的访问令牌加入吊销列表
+	revokeAccessTokens(rdb, jtis)
+
+	pipe := rdb.Pipeline()
+	for _, id := range articleLikes {
+		pipe.HIncrBy(rctx, global.ARTICLE_LIKE_COUNT, id, -1)
+	}
+	for _, id := range commentLikes {
+		pipe.HIncrBy(rctx, global.COMMENT_LIKE_COUNT, id, -1)
+	}
+	pipe.Del(rctx, articleKey, commentKey, global.ONLINE_USER+uid)
+	if _, err := pipe.Exec(rctx); err != nil {
+		slog.Error("撤销注销账号的点赞失败", "user", userId, "err", err)
+	}
+	// 再切换一次即为取消回应, 同时减少计数
+	for typ, members := range reactions {
+		for _, member := range members {
+			id, emoji, _ := strings.Cut(member, ":")
+			targetId, err := strconv.Atoi(id)
+			if err != nil {
+				continue
+			}
+			if _, err := toggleReaction(rdb, typ, targetId, userId, emoji); err != nil {
+				slog.Error("撤销注销账号的表情回应失败", "user", userId, "member", member, "err", err)
+			}
+		}
+	}
+	slog.Info("删除注销账号", "user", userId)
+	return nil
+}
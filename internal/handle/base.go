@@ -48,6 +48,15 @@ type PageResult[T any] struct {
 	List  []T `json:"page_data"` //分页数据
 }
 
+// CursorResult
+// CursorResult[T any]
+//
+//	@Description:游标分页响应数据
+type CursorResult[T any] struct {
+	Next string `json:"next_cursor"` //下一页游标(为空表示没有更多数据)
+	List []T    `json:"page_data"`   //分页数据
+}
+
 // ReturnHttpResponse
 //
 //	@Description:	返回http码、业务码、消息、数据
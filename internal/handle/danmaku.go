@@ -0,0 +1,256 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/net/websocket"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+实时弹幕:
+  - 留言对外可见 (发布时无需审核, 或审核通过) 后发布到 Redis 频道, 每个实例订阅该频道并推送给本实例上的连接, 多实例部署时同样生效
+  - 前台优先使用 WebSocket (/message/ws), 不支持时降级为 SSE (/message/stream)
+  - 每个连接有固定大小的发送缓冲, 缓冲已满 (客户端消费过慢) 时断开该连接, 不会阻塞其他连接; 客户端重连后重新拉取留言列表即可
+  - 服务端定时发送心跳; WebSocket 客户端超过一定时间没有发送任何消息 (包括心跳回复) 时断开
+  - 同一 IP 在单个实例上的连接数有上限 (配置项 danmaku_max_conn)
+*/
+
+const (
+	defaultDanmakuMaxConn = 5                // 未配置 danmaku_max_conn 时, 同一 IP 的最大连接数
+	danmakuSendBuffer     = 32               // 每个连接的发送缓冲大小
+	danmakuHeartbeat      = 30 * time.Second // 心跳间隔
+	danmakuReadTimeout    = 75 * time.Second // WebSocket 客户端超过该时间没有任何消息时断开
+	danmakuWriteTimeout   = 10 * time.Second // 单次写入超时
+)
+
+// 推送给客户端的事件类型
+const (
+	DANMAKU_EVENT_MESSAGE = "danmaku" // 新的弹幕
+	DANMAKU_EVENT_PING    = "ping"    // 心跳
+)
+
+// DanmakuVO 推送给客户端的弹幕
+type DanmakuVO struct {
+	ID        int       `json:"id"`
+	Nickname  string    `json:"nickname"`
+	Avatar    string    `json:"avatar"`
+	Content   string    `json:"content"`
+	Speed     int       `json:"speed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DanmakuEvent 推送给客户端的事件
+type DanmakuEvent struct {
+	Type string     `json:"type"`
+	Data *DanmakuVO `json:"data,omitempty"`
+}
+
+// danmakuClient 一个 WebSocket/SSE 连接
+type danmakuClient struct {
+	ip   string
+	send chan []byte   // 待发送的事件
+	done chan struct{} // 关闭时断开连接
+	once sync.Once
+}
+
+// kick 断开连接, 可重复调用
+func (cl *danmakuClient) kick() {
+	cl.once.Do(func() { close(cl.done) })
+}
+
+// danmakuHub 本实例上的全部连接
+type danmakuHub struct {
+	mu      sync.Mutex
+	clients map[*danmakuClient]struct{}
+	perIP   map[string]int
+}
+
+var danmaku = &danmakuHub{
+	clients: make(map[*danmakuClient]struct{}),
+	perIP:   make(map[string]int),
+}
+
+// register 新增连接, 超过同一 IP 的连接数上限时返回 false
+func (h *danmakuHub) register(ip string, max int) (*danmakuClient, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if max > 0 && h.perIP[ip] >= max {
+		return nil, false
+	}
+	cl := &danmakuClient{
+		ip:   ip,
+		send: make(chan []byte, danmakuSendBuffer),
+		done: make(chan struct{}),
+	}
+	h.clients[cl] = struct{}{}
+	h.perIP[ip]++
+	return cl, true
+}
+
+func (h *danmakuHub) unregister(cl *danmakuClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[cl]; !ok {
+		return
+	}
+	delete(h.clients, cl)
+	if h.perIP[cl.ip]--; h.perIP[cl.ip] <= 0 {
+		delete(h.perIP, cl.ip)
+	}
+	cl.kick()
+}
+
+// broadcast 推送给本实例上的全部连接, 发送缓冲已满的连接直接断开
+func (h *danmakuHub) broadcast(msg []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for cl := range h.clients {
+		select {
+		case cl.send <- msg:
+		default:
+			slog.Debug("弹幕连接消费过慢, 断开连接", "ip", cl.ip)
+			cl.kick()
+		}
+	}
+}
+
+// RunDanmakuHub 订阅 Redis 弹幕频道, 推送给本实例上的连接, ctx 结束时退出
+func RunDanmakuHub(ctx context.Context, rdb *redis.Client) {
+	sub := rdb.Subscribe(ctx, global.DANMAKU_CHANNEL)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			danmaku.broadcast([]byte(msg.Payload))
+		}
+	}
+}
+
+// publishDanmaku 发布对外可见的留言到 Redis 弹幕频道
+func publishDanmaku(rdb *redis.Client, message *model.Message) {
+	data, err := json.Marshal(DanmakuEvent{
+		Type: DANMAKU_EVENT_MESSAGE,
+		Data: &DanmakuVO{
+			ID:        message.ID,
+			Nickname:  message.Nickname,
+			Avatar:    message.Avatar,
+			Content:   message.Content,
+			Speed:     message.Speed,
+			CreatedAt: message.CreatedAt,
+		},
+	})
+	if err != nil {
+		slog.Error("弹幕序列化失败", "id", message.ID, "err", err)
+		return
+	}
+	if err := rdb.Publish(rctx, global.DANMAKU_CHANNEL, data).Err(); err != nil {
+		slog.Warn("发布弹幕失败", "id", message.ID, "err", err)
+	}
+}
+
+var danmakuPing, _ = json.Marshal(DanmakuEvent{Type: DANMAKU_EVENT_PING})
+
+// danmakuConnect 新增连接, 超过同一 IP 的连接数上限时直接返回错误响应
+// IP 为可信代理解析出的客户端 IP, 不能通过伪造 X-Forwarded-For 等请求头绕过上限
+func danmakuConnect(c *gin.Context) (*danmakuClient, bool) {
+	max := model.GetConfigInt(GetDB(c), global.CONFIG_DANMAKU_MAX_CONN, defaultDanmakuMaxConn)
+	cl, ok := danmaku.register(c.ClientIP(), max)
+	if !ok {
+		ReturnError(c, global.ErrDanmakuConnLimit, nil)
+		return nil, false
+	}
+	return cl, true
+}
+
+// DanmakuWebSocket 通过 WebSocket 接收实时弹幕
+// 弹幕为公开内容, 不校验 Origin; 客户端收到 ping 后回复任意消息即可保持连接
+func (*Front) DanmakuWebSocket(c *gin.Context) {
+	cl, ok := danmakuConnect(c)
+	if !ok {
+		return
+	}
+	defer danmaku.unregister(cl)
+
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		// 读取客户端消息只用于判断连接是否存活
+		ws.MaxPayloadBytes = 512
+		go func() {
+			defer cl.kick()
+			var msg string
+			for {
+				ws.SetReadDeadline(time.Now().Add(danmakuReadTimeout))
+				if err := websocket.Message.Receive(ws, &msg); err != nil {
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(danmakuHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			var msg []byte
+			select {
+			case <-cl.done:
+				return
+			case msg = <-cl.send:
+			case <-heartbeat.C:
+				msg = danmakuPing
+			}
+			ws.SetWriteDeadline(time.Now().Add(danmakuWriteTimeout))
+			if err := websocket.Message.Send(ws, string(msg)); err != nil {
+				return
+			}
+		}
+	}}.ServeHTTP(c.Writer, c.Request)
+}
+
+// DanmakuStream 通过 SSE 接收实时弹幕, 用于不支持 WebSocket 的环境
+func (*Front) DanmakuStream(c *gin.Context) {
+	cl, ok := danmakuConnect(c)
+	if !ok {
+		return
+	}
+	defer danmaku.unregister(cl)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush() // 立即返回响应头, 客户端不必等到第一条事件才建立连接
+
+	rc := http.NewResponseController(c.Writer)
+	heartbeat := time.NewTicker(danmakuHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		event, msg := DANMAKU_EVENT_MESSAGE, []byte(nil)
+		select {
+		case <-cl.done:
+			return false
+		case <-c.Request.Context().Done():
+			return false
+		case msg = <-cl.send:
+		case <-heartbeat.C:
+			event, msg = DANMAKU_EVENT_PING, danmakuPing
+		}
+		rc.SetWriteDeadline(time.Now().Add(danmakuWriteTimeout))
+		c.SSEvent(event, string(msg))
+		return true
+	})
+}
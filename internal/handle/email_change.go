@@ -0,0 +1,193 @@
+package handle
+
+import (
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+修改邮箱:
+  - 用户提交新邮箱 (密码登录的账号需要验证当前密码), 向新邮箱发送确认链接, 同时通知旧邮箱
+  - 点击确认链接后才在同一个事务中修改用户名 (登录邮箱) 和用户信息中的邮箱, 确认时再次检查邮箱是否已被使用
+  - 确认链接一次有效且有有效期, Redis 中只保存令牌的摘要
+*/
+
+// 修改邮箱链接的有效期, 以及同一用户两次请求之间的最短间隔
+const (
+	emailChangeExpire   = 30 * time.Minute
+	emailChangeInterval = time.Minute
+)
+
+type ChangeEmailReq struct {
+	Email    string `json:"email" binding:"required,email,max=30"`
+	Password string `json:"password"` // 当前密码, 第三方登录创建的账号不需要
+}
+
+// ChangeEmail 请求修改当前用户的邮箱: 向新邮箱发送确认链接, 并通知旧邮箱
+func (*User) ChangeEmail(c *gin.Context) {
+	var req ChangeEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	email := utils.Format(req.Email)
+
+	db, rdb := GetDB(c), GetRDB(c)
+	auth, _ := CurrentUserAuth(c)
+	if auth.LoginType != model.LOGIN_TYPE_OAUTH && !utils.BcryptCheck(req.Password, auth.Password) {
+		ReturnError(c, global.ErrPassword, nil)
+		return
+	}
+	info, err := model.GetUserInfoById(db, auth.UserInfoId)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if email == utils.Format(info.Email) || email == utils.Format(auth.Username) {
+		ReturnError(c, global.ErrEmailSame, nil)
+		return
+	}
+	exist, err := model.ExistUserEmail(db, email, auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if exist {
+		ReturnError(c, global.ErrEmailTaken, nil)
+		return
+	}
+
+	first, err := rdb.SetNX(rctx, global.EMAIL_CHANGE_LIMIT+strconv.Itoa(auth.ID), true, emailChangeInterval).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	if !first {
+		ReturnError(c, global.ErrEmailFrequent, nil)
+		return
+	}
+
+	// Redis 中只保存令牌的摘要, 值为 "用户id:新邮箱"
+	token := utils.RandomToken(32)
+	value := strconv.Itoa(auth.ID) + ":" + email
+	if err := rdb.Set(rctx, global.EMAIL_CHANGE+utils.SHA256(token), value, emailChangeExpire).Err(); err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+
+	err = utils.EnqueueEmail(rctx, rdb, utils.EmailTask{
+		To:       email,
+		Subject:  "确认修改邮箱",
+		Template: "email-change.tpl",
+		Data: map[string]string{
+			"UserName": info.Nickname,
+			"Email":    email,
+			"URL":      utils.GetPublicURL("/confirm-email?token=" + token),
+			"Expire":   strconv.Itoa(int(emailChangeExpire.Minutes())),
+		},
+	})
+	if err != nil {
+		ReturnError(c, global.ErrSendEmail, err)
+		return
+	}
+
+	// 通知旧邮箱, 账号被盗用时用户可以及时发现; 通知失败不影响修改
+	if old := currentEmail(auth, info); old != "" {
+		err = utils.EnqueueEmail(rctx, rdb, utils.EmailTask{
+			To:       old,
+			Subject:  "邮箱修改提醒",
+			Template: "email-change-notice.tpl",
+			Data: map[string]string{
+				"UserName": info.Nickname,
+				"Email":    maskEmail(email),
+			},
+		})
+		if err != nil {
+			slog.Error("修改邮箱提醒邮件入队失败", "user", auth.ID, "err", err)
+		}
+	}
+	ReturnSuccess(c, nil)
+}
+
+// currentEmail 用户当前的邮箱: 优先使用用户信息中的邮箱, 没有时使用用户名 (注册邮箱)
+func currentEmail(auth *model.UserAuth, info *model.UserInfo) string {
+	if info.Email != "" {
+		return info.Email
+	}
+	if strings.Contains(auth.Username, "@") {
+		return auth.Username
+	}
+	return ""
+}
+
+// maskEmail 隐藏邮箱用户名的中间部分, 例如 alice@example.com => al***@example.com
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	name := email[:at]
+	if len(name) > 2 {
+		name = name[:2]
+	}
+	return name + "***" + email[at:]
+}
+
+type ConfirmEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ConfirmEmail 通过邮件中的链接确认修改邮箱, 令牌只能使用一次
+//
+//	@Summary		确认修改邮箱
+//	@Description	使用新邮箱收到的确认链接中的令牌完成修改, 同时修改登录邮箱和用户信息中的邮箱
+//	@Tags			UserAuth
+//	@Param			form	body	ConfirmEmailReq	true	"令牌"
+//	@Accept			json
+//	@Produce		json
+//	@Success		0	{object}	Response[any]
+//	@Router			/email/confirm [post]
+func (*UserAuth) ConfirmEmail(c *gin.Context) {
+	var req ConfirmEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	// GETDEL 保证令牌只能使用一次
+	value, err := GetRDB(c).GetDel(rctx, global.EMAIL_CHANGE+utils.SHA256(req.Token)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			ReturnError(c, global.ErrEmailToken, nil)
+			return
+		}
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	id, email, _ := strings.Cut(value, ":")
+	uid, err := strconv.Atoi(id)
+	if err != nil || email == "" {
+		ReturnError(c, global.ErrEmailToken, nil)
+		return
+	}
+
+	updated, err := model.UpdateUserEmail(GetDB(c), uid, email)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if !updated {
+		ReturnError(c, global.ErrEmailTaken, nil)
+		return
+	}
+	slog.Info("用户修改邮箱", "user", uid)
+	ReturnSuccess(c, nil)
+}
@@ -1,24 +1,31 @@
 package handle
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"gin-blog-server/internal/global"
 	"gin-blog-server/internal/model"
 	"gin-blog-server/internal/utils"
-	"gin-blog-server/internal/utils/jwt"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"html/template"
 	"log/slog"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 )
 
 // LoginReq 登录请求结构体
 type LoginReq struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username  string `json:"username" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	CaptchaId string `json:"captcha_id"` // 失败次数过多时需要图片验证码 (见 /captcha)
+	Captcha   string `json:"captcha"`
 }
 
 type RegisterReq struct {
@@ -31,8 +38,15 @@ type LoginVO struct {
 	model.UserInfo
 	// 点赞 Set： 用于记录用户点赞过的文章，评论
 	Token          string   `json:"token"`
+	RefreshToken   string   `json:"refresh_token"` // 刷新令牌, 访问令牌过期后通过 /token/refresh 换取新的令牌
+	ExpiresIn      int64    `json:"expires_in"`    // 访问令牌有效期(秒)
 	ArticleLikeSet []string `json:"article_like_set"`
 	CommentLikeSet []string `json:"comment_like_set"`
+
+	// 两步验证: 需要两步验证时只返回预认证令牌, 其他字段为空
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	PreAuthToken      string `json:"pre_auth_token,omitempty"` // 预认证令牌, 通过 /login/2fa 提交验证码
+	TwoFactorSetup    bool   `json:"two_factor_setup"`         // 角色要求开启两步验证但尚未开启, 需要引导用户设置
 }
 type UserAuth struct{}
 
@@ -55,26 +69,64 @@ func (*UserAuth) Login(c *gin.Context) {
 		ReturnError(c, global.ErrRequest, err)
 		return
 	}
-	// 获取数据库和 Redis 客户端实例
+	// 获取数据库实例
 	db := GetDB(c)
-	rdb := GetRDB(c)
 
 	userAuth, err := model.GetUserAuthInfoByName(db, req.Username)
-	if err != nil {
-		// 如果用户不存在，返回错误信息
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ReturnError(c, global.ErrUserNotExist, nil)
-			return
-		}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
 
-	if !utils.BcryptCheck(req.Password, userAuth.Password) {
-		// 密码错误，返回错误信息
-		ReturnError(c, global.ErrPassword, nil)
+	// 失败次数过多时冷却/锁定, 或者需要图片验证码
+	guard := newLoginGuard(c, req.Username, userAuth)
+	if !guard.checkLocked(c) || !guard.checkCaptcha(c, req.CaptchaId, req.Captcha) {
+		return
+	}
+
+	// 用户不存在时也校验一次密码, 与密码错误返回同样的错误, 不泄露账号是否存在
+	hash := dummyPasswordHash()
+	if userAuth != nil {
+		hash = userAuth.Password
+	}
+	if !utils.BcryptCheck(req.Password, hash) || userAuth == nil {
+		guard.fail(c)
+		ReturnError(c, global.ErrCredentials, nil)
+		return
+	}
+	if userAuth.IsDisable {
+		ReturnError(c, global.ErrUserDisabled, nil)
 		return
 	}
+
+	loginWithSecondFactor(c, userAuth)
+}
+
+// loginWithSecondFactor 第一步身份验证 (密码或第三方登录) 通过后:
+// 开启了两步验证时只返回预认证令牌, 通过 /login/2fa 提交验证码后才完成登录; 否则直接完成登录
+func loginWithSecondFactor(c *gin.Context, userAuth *model.UserAuth) {
+	enabled, err := model.IsTwoFactorEnabled(GetDB(c), userAuth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if enabled {
+		token, err := issuePreAuthToken(GetRDB(c), userAuth.ID)
+		if err != nil {
+			ReturnError(c, global.ErrRedisOp, err)
+			return
+		}
+		ReturnSuccess(c, LoginVO{TwoFactorRequired: true, PreAuthToken: token})
+		return
+	}
+	completeLogin(c, userAuth)
+}
+
+// completeLogin 身份验证 (密码, 以及开启时的两步验证) 全部通过后, 签发令牌并记录登录状态
+func completeLogin(c *gin.Context, userAuth *model.UserAuth) {
+	db := GetDB(c)
+	rdb := GetRDB(c)
+
 	// 获取请求中的 IP 地址和 IP 来源信息
 	// FIXME: 可能无法正确读取 IP 地址，这需要解决(qpy:因为.xdb数据库原因)
 	//ipAddress := utils.IP.GetIpAddress(c)
@@ -113,44 +165,76 @@ func (*UserAuth) Login(c *gin.Context) {
 		ReturnError(c, global.ErrRedisOp, err)
 		return
 	}
-	// 登录信息验证通过后，生成 JWT Token
-	// UUID 生成方法：可以使用 ip 地址、浏览器信息和操作系统信息来生成唯一标识符（具体实现可调整）
-	// 这里使用 jwt.GenToken 来生成 Token
-	conf := global.GetConfig().JWT
-	token, err := jwt.GenToken(conf.Secret, conf.Issuer, int(conf.Expire), userAuth.ID, roleIds)
+	// 登录信息验证通过后，签发短期的访问令牌 (JWT) 和用于续期的刷新令牌
+	token, err := issueTokens(db, userAuth.ID, roleIds, "")
 	if err != nil {
 		// 生成 token 出错，返回错误
 		ReturnError(c, global.ErrTokenCreate, err)
 		return
 	}
+	// 记录登录会话 (设备、IP), 用户可以查看和下线自己的登录设备
+	if err := recordSession(c, db, userAuth.ID, token); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
 	// 更新用户登录信息,包括 IP 地址和上次登录时间
 	//err = model.UpdateUserLoginInfo(db, userAuth.ID, ipAddress, ipSource)
 	//if err != nil {
 	//	// 更新用户登录信息出错，返回错误
 	//	ReturnError(c, global.ErrDbOp, err)
 	//}
+	// 角色要求两步验证但尚未开启, 登录后只能设置两步验证, 不能访问后台接口
+	required, err := model.IsTwoFactorRequired(db, userAuth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	enabled, err := model.IsTwoFactorEnabled(db, userAuth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	setup := required && !enabled
+	clearLoginFailures(rdb, userAuth.ID)
 	//登录成功记录日志
 	slog.Info("用户登录成功: " + userAuth.Username)
 	//使用session记录用户登录信息
 	session := sessions.Default(c)
 	session.Set(global.CTX_USER_AUTH, userAuth.ID)
-	session.Save() //保存session
+	session.Set(global.CTX_TOKEN_ID, token.jti) // 令牌被吊销时 session 同时失效
+	session.Save()                              //保存session
 
 	// 删除 Redis 中的用户离线状态标识
 	offlineKey := global.OFFLINE_USER + strconv.Itoa(userAuth.ID)
 	rdb.Del(rctx, offlineKey).Result()
 
 	ReturnSuccess(c, LoginVO{
-		UserInfo:       *userInfo,      // 返回用户信息
-		ArticleLikeSet: articleLikeSet, // 返回用户的文章点赞记录
-		CommentLikeSet: commentLikeSet, // 返回用户的评论点赞记录
-		Token:          token,          // 返回生成的 JWT Token
+		UserInfo:       *userInfo,          // 返回用户信息
+		ArticleLikeSet: articleLikeSet,     // 返回用户的文章点赞记录
+		CommentLikeSet: commentLikeSet,     // 返回用户的评论点赞记录
+		Token:          token.Token,        // 返回生成的 JWT Token
+		RefreshToken:   token.RefreshToken, // 返回刷新令牌
+		ExpiresIn:      token.ExpiresIn,    // 访问令牌有效期
+		TwoFactorSetup: setup,              // 是否需要引导用户设置两步验证
 	})
 }
 
+// 注册邮箱验证链接的有效期, 同一邮箱两次发送验证邮件的最短间隔, 以及每小时最多发送次数
+const (
+	emailVerifyExpire   = 30 * time.Minute
+	emailVerifyInterval = time.Minute
+	emailVerifyHourly   = 5
+)
+
+// pendingUser 等待邮箱验证的注册信息, 保存在 Redis 中, 密码已经过 bcrypt 加密
+type pendingUser struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
 // Register 完成注册功能
-// 首先检查用户名是否存在，避免重复注册；其次吧用户输入的信息加密保存在 redis 中，等待验证
-// 在以下情况下会出错：1-用户邮箱已经注册过；2-用户邮箱无效等原因导致邮件发送失败
+// 首先检查用户名是否存在，避免重复注册；其次把注册信息 (密码已加密) 保存在 redis 中，向邮箱发送验证链接，验证通过后完成注册
+// 在以下情况下会出错：1-用户邮箱已经注册过；2-发送过于频繁；3-验证邮件入队失败
 //
 //	@Summary		注册
 //	@Description	注册
@@ -186,143 +270,236 @@ func (*UserAuth) Register(c *gin.Context) {
 		ReturnError(c, global.ErrUserExist, err)
 		return
 	}
-	//通过邮箱验证后完成 注册
-	info := utils.GenEmailVerificationInfo(req.Username, req.Password)
-	err = SetMailInfo(GetRDB(c), info, 5*time.Minute) //5 分钟内验证有效
+
+	if !verifyEmailLimit(c, req.Username) {
+		return
+	}
+	pass, err := utils.BcryptHash(req.Password)
 	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	sendVerifyEmail(c, &pendingUser{Email: req.Username, Password: pass})
+}
+
+type ResendVerifyEmailReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResendVerifyEmail 重新发送注册验证邮件, 之前发送的验证链接随即失效
+// 没有等待验证的注册信息 (已过期或已完成注册) 时需要重新注册; 为避免通过该接口探测邮箱, 此时同样返回成功 (不发送邮件)
+//
+//	@Summary		重新发送验证邮件
+//	@Description	重新发送注册验证邮件, 同一邮箱有发送频率限制
+//	@Tags			UserAuth
+//	@Param			form	body	ResendVerifyEmailReq	true	"注册邮箱"
+//	@Accept			json
+//	@Produce		json
+//	@Success		0	{object}	Response[any]
+//	@Router			/email/resend [post]
+func (*UserAuth) ResendVerifyEmail(c *gin.Context) {
+	var req ResendVerifyEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	email := utils.Format(req.Email)
+
+	// 频率限制与邮箱是否在等待验证无关, 响应不会暴露邮箱的状态
+	if !verifyEmailLimit(c, email) {
+		return
+	}
+	rdb := GetRDB(c)
+	hash, err := rdb.Get(rctx, global.EMAIL_VERIFY_PENDING+email).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			ReturnSuccess(c, nil)
+			return
+		}
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	data, err := rdb.Get(rctx, global.EMAIL_VERIFY+hash).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			ReturnSuccess(c, nil)
+			return
+		}
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	var pending pendingUser
+	if err := json.Unmarshal(data, &pending); err != nil {
+		slog.Error("解析等待验证的注册信息失败", "err", err)
+		ReturnSuccess(c, nil)
+		return
+	}
+	sendVerifyEmail(c, &pending)
+}
+
+// verifyEmailLimit 验证邮件的发送频率限制: 同一邮箱两次发送至少间隔 emailVerifyInterval, 一小时内最多 emailVerifyHourly 封
+// 一小时的窗口从最后一次发送开始计算; 超过限制时返回错误响应
+func verifyEmailLimit(c *gin.Context, email string) bool {
+	rdb := GetRDB(c)
+	first, err := rdb.SetNX(rctx, global.EMAIL_VERIFY_LIMIT+email, true, emailVerifyInterval).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return false
+	}
+	if !first {
+		ReturnError(c, global.ErrEmailFrequent, nil)
+		return false
+	}
+
+	hourlyKey := global.EMAIL_VERIFY_LIMIT + "hourly:" + email
+	pipe := rdb.TxPipeline()
+	count := pipe.Incr(rctx, hourlyKey)
+	pipe.Expire(rctx, hourlyKey, time.Hour)
+	if _, err := pipe.Exec(rctx); err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return false
+	}
+	if count.Val() > emailVerifyHourly {
+		ReturnError(c, global.ErrEmailFrequent, nil)
+		return false
+	}
+	return true
+}
+
+// sendVerifyEmail 保存等待验证的注册信息并发送验证邮件, 同一邮箱只保留最新的验证链接
+// Redis 中只保存令牌的摘要; 调用前需要通过 verifyEmailLimit 检查发送频率
+func sendVerifyEmail(c *gin.Context, pending *pendingUser) {
+	rdb := GetRDB(c)
+	data, err := json.Marshal(pending)
+	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	token := utils.RandomToken(32)
+	hash := utils.SHA256(token)
+	pendingKey := global.EMAIL_VERIFY_PENDING + pending.Email
+	old, err := rdb.Get(rctx, pendingKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
 		ReturnError(c, global.ErrRedisOp, err)
 		return
 	}
-	EmailData := utils.GetEmailData(req.Username, info)
-	err = utils.SendEmail(req.Username, EmailData)
+	pipe := rdb.TxPipeline()
+	if old != "" {
+		pipe.Del(rctx, global.EMAIL_VERIFY+old)
+	}
+	pipe.Set(rctx, global.EMAIL_VERIFY+hash, data, emailVerifyExpire)
+	pipe.Set(rctx, pendingKey, hash, emailVerifyExpire)
+	if _, err := pipe.Exec(rctx); err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+
+	err = utils.EnqueueEmail(rctx, rdb, utils.EmailTask{
+		To:       pending.Email,
+		Subject:  "邮箱验证",
+		Template: "email-verify.tpl",
+		Data: map[string]string{
+			"UserName": pending.Email,
+			"URL":      utils.GetEmailVerifyURL(token),
+			"Expire":   strconv.Itoa(int(emailVerifyExpire.Minutes())),
+		},
+	})
 	if err != nil {
 		ReturnError(c, global.ErrSendEmail, err)
+		return
 	}
 	ReturnSuccess(c, nil)
 }
 
 // VerifyCode 邮箱验证
-// 当用户点击邮箱中的链接时，会携带info（加密后的帐号密码）向这个接口发送请求。
-// Verify会检查info是否存在redis中，若存在则认证成功，完成注册
-// 会在以下方面出错： 1. 发送信息中没有info 2. info不存在redis中(已过期) 3. 创造新用户失败（数据库操作失败）
+// 当用户点击邮箱中的链接时，会携带 token 向这个接口发送请求。
+// 令牌只能使用一次, 对应的注册信息存在时完成注册, 并渲染结果页面
+// 会在以下方面出错： 1. 链接中没有 token 2. token 不存在 (已过期或已使用) 3. 邮箱已被注册 4. 创造新用户失败（数据库操作失败）
 func (*UserAuth) VerifyCode(c *gin.Context) {
-	var code string
-	if code = c.Query("info"); code == "" {
-		returnErrorPage(c)
+	token := c.Query("token")
+	if token == "" {
+		renderVerifyPage(c, http.StatusBadRequest, false, "验证链接无效")
 		return
 	}
-	// 验证是否在 redis 数据库中
-	ifExist, err := GetMailInfo(GetRDB(c), code)
+
+	rdb := GetRDB(c)
+	hash := utils.SHA256(token)
+	data, err := rdb.GetDel(rctx, global.EMAIL_VERIFY+hash).Bytes()
 	if err != nil {
-		returnErrorPage(c)
+		if errors.Is(err, redis.Nil) {
+			renderVerifyPage(c, http.StatusBadRequest, false, "验证链接已过期或已使用，请重新注册或重新发送验证邮件")
+			return
+		}
+		slog.Error("获取注册信息失败", "err", err)
+		renderVerifyPage(c, http.StatusInternalServerError, false, "请稍后重试")
 		return
 	}
-	if !ifExist {
-		returnErrorPage(c)
+	var pending pendingUser
+	if err := json.Unmarshal(data, &pending); err != nil {
+		renderVerifyPage(c, http.StatusBadRequest, false, "验证链接无效")
 		return
 	}
-	err = DeleteMailInfo(GetRDB(c), code)
+	rdb.Del(rctx, global.EMAIL_VERIFY_PENDING+pending.Email)
+
+	// 等待验证期间该邮箱可能已经完成注册 (例如同时注册了两次)
+	db := GetDB(c)
+	exist, err := model.ExistUserEmail(db, pending.Email, 0)
 	if err != nil {
-		returnErrorPage(c)
+		slog.Error("查询注册邮箱失败", "err", err)
+		renderVerifyPage(c, http.StatusInternalServerError, false, "请稍后重试")
 		return
 	}
-	// 从 code 中解析出来 用户名 和 密码
-	username, password, err := utils.ParseEmailVerificationInfo(code)
-	if err != nil {
-		returnErrorPage(c)
+	if exist {
+		renderVerifyPage(c, http.StatusConflict, false, "该邮箱已经注册，请直接登录")
 		return
 	}
 	// 创建用户
-	_, _, _, err = model.CreateNewUser(GetDB(c), username, password)
-	if err != nil {
-		returnErrorPage(c)
+	if _, _, _, err = model.CreateNewUser(db, pending.Email, pending.Password); err != nil {
+		slog.Error("创建用户失败", "err", err)
+		renderVerifyPage(c, http.StatusInternalServerError, false, "请稍后重试")
 		return
 	}
 	// 注册成功，返回成功页面
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(`
-        <!DOCTYPE html>
-        <html lang="zh-CN">
-        <head>
-            <meta charset="UTF-8">
-            <meta name="viewport" content="width=device-width, initial-scale=1.0">
-            <title>注册成功</title>
-            <style>
-                body {
-                    font-family: Arial, sans-serif;
-                    background-color: #f4f4f4;
-                    display: flex;
-                    justify-content: center;
-                    align-items: center;
-                    height: 100vh;
-                    margin: 0;
-                }
-                .container {
-                    background-color: #fff;
-                    padding: 20px;
-                    border-radius: 8px;
-                    box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);
-                    text-align: center;
-                }
-                h1 {
-                    color: #5cb85c;
-                }
-                p {
-                    color: #333;
-                }
-            </style>
-        </head>
-        <body>
-            <div class="container">
-                <h1>注册成功</h1>
-                <p>恭喜您，注册成功！</p>
-            </div>
-        </body>
-        </html>
-    `))
+	renderVerifyPage(c, http.StatusOK, true, "恭喜您，注册成功！")
 }
 
-// c.Data 可以用来直接返回原始字节数据，而不是使用 Gin 中的 c.JSON、c.String 等方法。它特别适合于返回 非结构化数据，例如 HTML 页面、文本或文件。
-func returnErrorPage(c *gin.Context) {
-	c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(`
-        <!DOCTYPE html>
-        <html lang="zh-CN">
-        <head>
-            <meta charset="UTF-8">
-            <meta name="viewport" content="width=device-width, initial-scale=1.0">
-            <title>注册失败</title>
-            <style>
-                body {
-                    font-family: Arial, sans-serif;
-                    background-color: #f4f4f4;
-                    display: flex;
-                    justify-content: center;
-                    align-items: center;
-                    height: 100vh;
-                    margin: 0;
-                }
-                .container {
-                    background-color: #fff;
-                    padding: 20px;
-                    border-radius: 8px;
-                    box-shadow: 0 0 10px rgba(0, 0, 0, 0.1);
-                    text-align: center;
-                }
-                h1 {
-                    color: #d9534f;
-                }
-                p {
-                    color: #333;
-                }
-            </style>
-        </head>
-        <body>
-            <div class="container">
-                <h1>注册失败</h1>
-                <p>请重试。</p>
-            </div>
-        </body>
-        </html>
-    `))
+// 页面模版目录, 与邮件模版分开, 页面模版不使用邮件的 base.tpl
+const pageTemplateDir = "./assets/templates/page"
+
+// pageTemplates 页面模版, 第一次渲染时解析整个目录, 之后复用
+var pageTemplates = sync.OnceValues(func() (*template.Template, error) {
+	return template.ParseGlob(filepath.Join(pageTemplateDir, "*.tpl"))
+})
+
+// renderPage 渲染页面模版 name (文件名), 模版出错时返回纯文本 fallback
+func renderPage(c *gin.Context, status int, name string, data map[string]any, fallback string) {
+	tpl, err := pageTemplates()
+	if err != nil {
+		slog.Error("解析页面模版失败", "err", err)
+		c.String(status, fallback)
+		return
+	}
+	var body bytes.Buffer
+	if err := tpl.ExecuteTemplate(&body, name, data); err != nil {
+		slog.Error("渲染页面失败", "name", name, "err", err)
+		c.String(status, fallback)
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", body.Bytes())
+}
+
+// renderVerifyPage 渲染注册邮箱验证的结果页面
+func renderVerifyPage(c *gin.Context, status int, success bool, message string) {
+	title := "注册失败"
+	if success {
+		title = "注册成功"
+	}
+	renderPage(c, status, "email-verify-result.tpl", map[string]any{
+		"Title":   title,
+		"Success": success,
+		"Message": message,
+		"HomeURL": utils.GetPublicURL("/"),
+	}, title+": "+message)
 }
 
 // Logout 退出登录
@@ -338,6 +515,9 @@ func (*UserAuth) Logout(c *gin.Context) {
 	// 防止其他请求设置干扰
 	c.Set(global.CTX_USER_AUTH, nil)
 
+	// 吊销当前登录的令牌, 已泄露的令牌也随之失效
+	revokeSession(c)
+
 	//// 已经退出登录
 	auth, _ := CurrentUserAuth(c)
 	if auth == nil {
@@ -347,6 +527,7 @@ func (*UserAuth) Logout(c *gin.Context) {
 
 	session := sessions.Default(c)
 	session.Delete(global.CTX_USER_AUTH)
+	session.Delete(global.CTX_TOKEN_ID)
 	session.Save()
 
 	// 删除缓存中的用户信息
@@ -355,3 +536,152 @@ func (*UserAuth) Logout(c *gin.Context) {
 	rdb.Del(rctx, onlineKey)
 	ReturnSuccess(c, nil)
 }
+
+// 重置密码链接的有效期, 以及同一邮箱两次请求之间的最短间隔
+const (
+	passwordResetExpire   = 30 * time.Minute
+	passwordResetInterval = time.Minute
+)
+
+type ForgotPasswordReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPassword 忘记密码: 向邮箱发送重置密码链接
+// 无论邮箱是否注册都返回成功, 不泄露邮箱的注册情况
+//
+//	@Summary		忘记密码
+//	@Description	向注册邮箱发送重置密码链接, 链接一次有效且有有效期
+//	@Tags			UserAuth
+//	@Param			form	body	ForgotPasswordReq	true	"邮箱"
+//	@Accept			json
+//	@Produce		json
+//	@Success		0	{object}	Response[any]
+//	@Router			/password/forgot [post]
+func (*UserAuth) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	email := utils.Format(req.Email)
+	// 以下任何失败都只记录日志, 统一返回成功
+	defer ReturnSuccess(c, nil)
+
+	db, rdb := GetDB(c), GetRDB(c)
+	first, err := rdb.SetNX(rctx, global.PASSWORD_RESET_LIMIT+email, true, passwordResetInterval).Result()
+	if err != nil || !first {
+		return
+	}
+	auth, err := model.GetUserAuthByEmail(db, email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Error("查询重置密码用户失败", "err", err)
+		}
+		return
+	}
+
+	// Redis 中只保存令牌的摘要, 同时记录到用户的令牌集合中, 修改密码后作废全部令牌
+	token := utils.RandomToken(32)
+	digest, userKey := utils.SHA256(token), global.PASSWORD_RESET_USER+strconv.Itoa(auth.ID)
+	pipe := rdb.TxPipeline()
+	pipe.Set(rctx, global.PASSWORD_RESET+digest, auth.ID, passwordResetExpire)
+	pipe.SAdd(rctx, userKey, digest)
+	pipe.Expire(rctx, userKey, passwordResetExpire)
+	if _, err := pipe.Exec(rctx); err != nil {
+		slog.Error("保存重置密码令牌失败", "user", auth.ID, "err", err)
+		return
+	}
+
+	name := email
+	if info, err := model.GetUserInfoById(db, auth.UserInfoId); err == nil && info.Nickname != "" {
+		name = info.Nickname
+	}
+	err = utils.EnqueueEmail(rctx, rdb, utils.EmailTask{
+		To:       email,
+		Subject:  "重置密码",
+		Template: "password-reset.tpl",
+		Data: map[string]string{
+			"UserName": name,
+			"URL":      utils.GetPublicURL("/reset-password?token=" + token),
+			"Expire":   strconv.Itoa(int(passwordResetExpire.Minutes())),
+		},
+	})
+	if err != nil {
+		slog.Error("重置密码邮件入队失败", "user", auth.ID, "err", err)
+	}
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=4,max=20"`
+}
+
+// ResetPassword 通过邮件中的链接重置密码
+// 令牌只能使用一次; 重置后作废该用户其他未使用的重置链接, 并吊销全部会话和令牌, 需要重新登录
+//
+//	@Summary		重置密码
+//	@Description	使用重置密码链接中的令牌设置新密码, 所有已登录的设备都需要重新登录
+//	@Tags			UserAuth
+//	@Param			form	body	ResetPasswordReq	true	"令牌与新密码"
+//	@Accept			json
+//	@Produce		json
+//	@Success		0	{object}	Response[any]
+//	@Router			/password/reset [post]
+func (*UserAuth) ResetPassword(c *gin.Context) {
+	var req ResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db, rdb := GetDB(c), GetRDB(c)
+	// GETDEL 保证令牌只能使用一次
+	uid, err := rdb.GetDel(rctx, global.PASSWORD_RESET+utils.SHA256(req.Token)).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			ReturnError(c, global.ErrResetToken, nil)
+			return
+		}
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+
+	hashPassword, err := utils.BcryptHash(req.Password)
+	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	if err := model.UpdateUserPassword(db, uid, hashPassword); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	clearPasswordResetTokens(rdb, uid)
+
+	// 强制下线, 并吊销全部令牌
+	rdb.Del(rctx, global.ONLINE_USER+strconv.Itoa(uid))
+	rdb.Set(rctx, global.OFFLINE_USER+strconv.Itoa(uid), uid, time.Hour)
+	if err := revokeUserTokens(db, rdb, uid); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	slog.Info("用户通过邮件重置密码", "user", uid)
+	ReturnSuccess(c, nil)
+}
+
+// clearPasswordResetTokens 作废用户全部未使用的重置密码令牌, 修改密码后调用; 失败只记录日志
+func clearPasswordResetTokens(rdb *redis.Client, uid int) {
+	userKey := global.PASSWORD_RESET_USER + strconv.Itoa(uid)
+	digests, err := rdb.SMembers(rctx, userKey).Result()
+	if err != nil {
+		slog.Error("获取重置密码令牌失败", "user", uid, "err", err)
+		return
+	}
+	keys := []string{userKey}
+	for _, digest := range digests {
+		keys = append(keys, global.PASSWORD_RESET+digest)
+	}
+	if err := rdb.Del(rctx, keys...).Err(); err != nil {
+		slog.Error("作废重置密码令牌失败", "user", uid, "err", err)
+	}
+}
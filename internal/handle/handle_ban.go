@@ -0,0 +1,211 @@
+package handle
+
+import (
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Ban struct{}
+
+// 生效中的 IP 封禁的缓存时间: 每个互动请求都要检查, 缓存避免每次查询数据库
+// 本实例新增/解除封禁时立即清空, 多实例部署时其他实例最多延迟这么久生效
+const ipBanCacheTTL = 30 * time.Second
+
+var ipBanCache struct {
+	mu      sync.Mutex
+	list    []model.Ban
+	expires time.Time
+}
+
+// ActiveIPBans 生效中的 IP 封禁 (带缓存), 缓存期间到期的封禁由调用方通过 Ban.Active 判断
+func ActiveIPBans(db *gorm.DB) ([]model.Ban, error) {
+	ipBanCache.mu.Lock()
+	defer ipBanCache.mu.Unlock()
+	now := time.Now()
+	if now.Before(ipBanCache.expires) {
+		return ipBanCache.list, nil
+	}
+	list, err := model.GetActiveIPBans(db)
+	if err != nil {
+		return nil, err
+	}
+	ipBanCache.list, ipBanCache.expires = list, now.Add(ipBanCacheTTL)
+	return list, nil
+}
+
+// clearIPBanCache 封禁变化后清空缓存, 下一个请求重新查询
+func clearIPBanCache() {
+	ipBanCache.mu.Lock()
+	defer ipBanCache.mu.Unlock()
+	ipBanCache.list, ipBanCache.expires = nil, time.Time{}
+}
+
+// BanQuery 封禁列表查询条件
+type BanQuery struct {
+	PageQuery
+	Type   string `form:"type" binding:"omitempty,oneof=user ip"` // 为空时查询全部类型
+	Active bool   `form:"active"`                                 // 只查询生效中的封禁
+}
+
+// GetList 封禁列表
+// @Summary 封禁列表
+// @Description 账号封禁和 IP 封禁列表, 可只查询生效中的封禁
+// @Tags Ban
+// @Param type query string false "类型: user | ip"
+// @Param active query bool false "只查询生效中的封禁"
+// @Param keyword query string false "搜索关键字 (IP/原因/昵称)"
+// @Param page_num query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[PageResult[model.BanVO]]
+// @Security ApiKeyAuth
+// @Router /ban/list [get]
+func (*Ban) GetList(c *gin.Context) {
+	var query BanQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	list, total, err := model.GetBanList(GetDB(c), query.Page, query.Size, query.Type, query.Active, query.Keyword)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, PageResult[model.BanVO]{
+		Total: int(total),
+		List:  list,
+		Size:  query.Size,
+		Page:  query.Page,
+	})
+}
+
+// AddBanReq 新增封禁
+type AddBanReq struct {
+	Type      string     `json:"type" binding:"required,oneof=user ip"`
+	UserId    int        `json:"user_id"` // 账号封禁时必填
+	IP        string     `json:"ip"`      // IP 封禁时必填, 单个 IP 或 CIDR 网段
+	Reason    string     `json:"reason" binding:"max=255"`
+	ExpiresAt *time.Time `json:"expires_at"` // 到期时间, 为空时永久封禁
+}
+
+// Add 新增封禁
+// @Summary 新增封禁
+// @Description 封禁账号或 IP/CIDR 网段, 可设置到期时间和原因
+// @Tags Ban
+// @Param form body AddBanReq true "封禁信息"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[model.Ban]
+// @Security ApiKeyAuth
+// @Router /ban [post]
+func (*Ban) Add(c *gin.Context) {
+	var req AddBanReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		ReturnError(c, global.ErrRequest, "到期时间必须晚于当前时间")
+		return
+	}
+
+	db := GetDB(c)
+	auth, _ := CurrentUserAuth(c)
+	ban := model.Ban{
+		Type:       req.Type,
+		Reason:     req.Reason,
+		ExpiresAt:  req.ExpiresAt,
+		OperatorId: auth.ID,
+		Operator:   auth.Username,
+	}
+	if auth.UserInfo != nil {
+		ban.Operator = auth.UserInfo.Nickname
+	}
+
+	switch req.Type {
+	case model.BAN_USER:
+		if req.UserId == 0 {
+			ReturnError(c, global.ErrRequest, "请选择要封禁的用户")
+			return
+		}
+		if req.UserId == auth.ID {
+			ReturnError(c, global.ErrRequest, "不能封禁自己")
+			return
+		}
+		if _, err := model.GetUserAuthInfoById(db, req.UserId); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ReturnError(c, global.ErrUserNotExist, nil)
+				return
+			}
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		ban.UserId = req.UserId
+	case model.BAN_IP:
+		ip, ok := normalizeBanIP(req.IP)
+		if !ok {
+			ReturnError(c, global.ErrRequest, "IP 或 CIDR 网段格式错误")
+			return
+		}
+		ban.IP = ip
+	}
+
+	if err := model.CreateBan(db, &ban); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	clearIPBanCache()
+	ReturnSuccess(c, ban)
+}
+
+// normalizeBanIP 校验并规范化 IP 或 CIDR 网段, 如 "10.0.0.1/8" => "10.0.0.0/8"
+func normalizeBanIP(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return "", false
+		}
+		return ipNet.String(), true
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "", false
+	}
+	return ip.String(), true
+}
+
+// Revoke 解除封禁
+// @Summary 解除封禁
+// @Description 批量解除封禁, 保留封禁记录
+// @Tags Ban
+// @Param data body []int true "封禁 id 列表"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[int]
+// @Security ApiKeyAuth
+// @Router /ban/revoke [put]
+func (*Ban) Revoke(c *gin.Context) {
+	var ids []int
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	rows, err := model.RevokeBans(GetDB(c), ids)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	clearIPBanCache()
+	ReturnSuccess(c, rows)
+}
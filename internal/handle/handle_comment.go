@@ -3,6 +3,7 @@ package handle
 import (
 	"gin-blog-server/internal/global"
 	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils/spam"
 	"github.com/gin-gonic/gin"
 )
 
@@ -57,9 +58,10 @@ func (*Comment) GetList(c *gin.Context) {
 
 // Delete 删除评论（批量）
 // @Summary 删除评论（批量）
-// @Description 根据 ID 数组删除评论
+// @Description 根据 ID 数组删除评论 (软删除, 评论树中保留占位)
 // @Tags Comment
 // @Param ids body []int true "评论 ID 数组"
+// @Param spam query bool false "是否标记为垃圾内容 (作为分类器的训练数据)"
 // @Accept json
 // @Produce json
 // @Success 0 {object} Response[int]
@@ -72,12 +74,29 @@ func (*Comment) Delete(c *gin.Context) {
 		return
 	}
 
-	result := GetDB(c).Model(&model.Comment{}).Delete(model.Comment{}, "id in ?", ids)
-	if result.Error != nil {
-		ReturnError(c, global.ErrDbOp, result.Error)
+	db := GetDB(c)
+	// 标记为垃圾内容时, 删除前取出评论内容作为训练数据; 普通删除不参与训练
+	var list []model.Comment
+	if isSpam := c.Query("spam") == "true"; isSpam {
+		var err error
+		if list, err = model.GetCommentsByIds(db, ids); err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+	}
+
+	// 软删除, 评论树中保留占位, 其下的回复仍然正常展示
+	count, err := model.SoftDeleteComments(db, ids)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
-	ReturnSuccess(c, result.RowsAffected)
+
+	rdb := GetRDB(c)
+	for _, comment := range list {
+		trainSpam(rdb, spam.KIND_COMMENT, comment.ID, comment.Content, true)
+	}
+	ReturnSuccess(c, count)
 }
 
 // UpdateReview 修改评论审核（批量）
@@ -97,11 +116,26 @@ func (*Comment) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	maps := map[string]any{"is_review": req.IsReview}
-	result := GetDB(c).Model(model.Comment{}).Where("id in ?", req.Ids).Updates(maps)
+	db := GetDB(c)
+	maps := map[string]any{"is_review": req.IsReview, "is_reject": false}
+	result := db.Model(model.Comment{}).Where("id in ?", req.Ids).Updates(maps)
 	if result.Error != nil {
 		ReturnError(c, global.ErrDbOp, result.Error)
 		return
 	}
+
+	// 审核通过的评论作为正常内容的训练数据, 并发送邮件通知
+	if req.IsReview {
+		list, err := model.GetCommentsByIds(db, req.Ids)
+		if err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		rdb := GetRDB(c)
+		for _, comment := range list {
+			trainSpam(rdb, spam.KIND_COMMENT, comment.ID, comment.Content, false)
+			notifyComment(db, rdb, &comment)
+		}
+	}
 	ReturnSuccess(c, result.RowsAffected)
 }
@@ -1,11 +1,16 @@
 package handle
 
 import (
+	"errors"
 	"gin-blog-server/internal/global"
 	"gin-blog-server/internal/model"
 	"gin-blog-server/internal/utils"
+	"gin-blog-server/internal/utils/spam"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 	"html/template"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -50,6 +55,7 @@ type FArticleQuery struct {
 	PageQuery
 	CategoryId int `form:"category_id"`
 	TagId      int `form:"tag_id"`
+	UserId     int `form:"user_id"` // 作者 (user_auth_id)
 }
 
 type ArchiveVO struct {
@@ -72,7 +78,7 @@ func (*Front) GetArticleList(c *gin.Context) {
 		return
 	}
 
-	list, _, err := model.GetBlogArticleList(GetDB(c), query.Page, query.Size, query.CategoryId, query.TagId)
+	list, _, err := model.GetBlogArticleList(GetDB(c), query.Page, query.Size, query.CategoryId, query.TagId, query.UserId)
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
@@ -138,6 +144,10 @@ func (*Front) GetArticleInfo(c *gin.Context) {
 	likeCount, _ := strconv.Atoi(rdb.HGet(rctx, global.ARTICLE_LIKE_COUNT, strconv.Itoa(id)).Val())
 	article.LikeCount = int64(likeCount)
 
+	// 表情回应
+	counts, mine := getReactions(rdb, model.REACTION_ARTICLE, []int{id}, commentViewer(c).UserId)
+	article.Reactions, article.MyReactions = counts[id], mine[id]
+
 	//评论数量
 	article.CommentCount, err = model.GetArticleCommentCount(db, id)
 	if err != nil {
@@ -156,7 +166,7 @@ func (*Front) GetArchiveList(c *gin.Context) {
 		return
 	}
 
-	list, total, err := model.GetBlogArticleList(GetDB(c), query.Page, query.Size, query.CategoryId, query.TagId)
+	list, total, err := model.GetBlogArticleList(GetDB(c), query.Page, query.Size, query.CategoryId, query.TagId, query.UserId)
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
@@ -295,8 +305,18 @@ type FCommentQuery struct {
 	Content     string `json:"content" form:"content"`
 	ParentId    int    `json:"parent_id" form:"parent_id"`
 	Type        int    `json:"type" form:"type"`
+	Sort        string `json:"sort" form:"sort"` // 排序方式: newest(默认) | oldest | liked
+}
+
+// FReplyQuery 回复列表游标分页查询
+type FReplyQuery struct {
+	Cursor string `form:"cursor"`    // 上一页返回的 next_cursor, 第一页为空
+	Size   int    `form:"page_size"` // 每页数量
 }
 
+// 评论列表中每个顶级评论预加载的回复数量, 更多回复通过 GetReplyListByCommentId 获取
+const commentPreviewReplies = 3
+
 // GetCommentList 获取评论列表
 func (*Front) GetCommentList(c *gin.Context) {
 	var query FCommentQuery
@@ -308,20 +328,15 @@ func (*Front) GetCommentList(c *gin.Context) {
 	db := GetDB(c)
 	rdb := GetRDB(c)
 
-	data, total, err := model.GetCommentVOList(db, query.Page, query.Size, query.TopicId, query.Type)
+	data, total, err := model.GetCommentVOList(db, query.Page, query.Size, query.TopicId, query.Type, query.Sort, commentPreviewReplies, commentViewer(c))
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
 
 	likeCountMap := rdb.HGetAll(rctx, global.COMMENT_LIKE_COUNT).Val()
-	for i, comment := range data {
-		if len(data[i].ReplyList) > 3 {
-			// 只显示三条回复
-			data[i].ReplyList = data[i].ReplyList[:3]
-		}
-		data[i].LikeCount, _ = strconv.Atoi(likeCountMap[strconv.Itoa(comment.ID)])
-	}
+	fillCommentLikeCount(data, likeCountMap)
+	fillCommentReactions(c, data)
 	ReturnSuccess(c, PageResult[model.CommentVO]{
 		List:  data,
 		Total: int(total),
@@ -331,46 +346,195 @@ func (*Front) GetCommentList(c *gin.Context) {
 
 }
 
+// fillCommentLikeCount 使用 Redis 中的点赞数填充评论树
+func fillCommentLikeCount(list []model.CommentVO, likeCountMap map[string]string) {
+	for i := range list {
+		list[i].LikeCount, _ = strconv.Atoi(likeCountMap[strconv.Itoa(list[i].ID)])
+		fillCommentLikeCount(list[i].ReplyList, likeCountMap)
+	}
+}
+
+// FAddCommentReq 新增评论, 回复时被回复者为父评论的作者 (不再使用 reply_user_id)
 type FAddCommentReq struct {
-	ReplyUserId int    `json:"reply_user_id" form:"reply_user_id"`
-	TopicId     int    `json:"topic_id" form:"topic_id"`
-	Content     string `json:"content" form:"content"`
-	ParentId    int    `json:"parent_id" form:"parent_id"`
-	Type        int    `json:"type" form:"type" validate:"required,min=1,max=3" label:"评论类型"`
+	TopicId  int    `json:"topic_id" form:"topic_id"`
+	Content  string `json:"content" form:"content" binding:"required,max=5000"` // Markdown
+	ParentId int    `json:"parent_id" form:"parent_id"`
+	Type     int    `json:"type" form:"type" validate:"required,min=1,max=3" label:"评论类型"`
 }
 
-// SaveComment 保存评论（只能新增，不能编辑）
-// TODO: 添加自定义头像和昵称留言功能（即可以不登录评论）
-// TODO: 开启邮箱通知用户功能
-// TODO: HTMLUtil.Filter 过滤 HTML 元素中的字符串...
+// SaveComment 新增评论 (编辑见 UpdateComment)
 func (*Front) SaveComment(c *gin.Context) {
 	var req FAddCommentReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ReturnError(c, global.ErrRequest, err)
 		return
 	}
-	// 过滤评论内容，防止 XSS 攻击
-	req.Content = template.HTMLEscapeString(req.Content)
+	// 评论内容为 Markdown, 保存时渲染为经过白名单过滤的 HTML, 防止 XSS 攻击
 	auth, _ := CurrentUserAuth(c)
 	db := GetDB(c)
 	isReview := model.GetConfigBool(db, global.CONFIG_IS_COMMENT_REVIEW)
 
+	content, review, ok := filterSensitive(c, req.Content, true)
+	if !ok {
+		return
+	}
+	req.Content = content
+	if review {
+		isReview = false
+	}
+
+	// 疑似垃圾内容转为待审核
+	suspect, ok := checkSpam(c, spam.KIND_COMMENT, req.Content, auth.ID)
+	if !ok {
+		return
+	}
+	if suspect {
+		isReview = false
+	}
+
+	users, ok := resolveMentions(c, req.Content)
+	if !ok {
+		return
+	}
+
 	var comment *model.Comment
 	var err error
 
-	if req.ReplyUserId == 0 { // 评论文章
+	// 根据父评论区分评论和回复: 回复游客评论时被回复者的 user_id 为 0, 不能作为判断依据
+	if req.ParentId == 0 { // 评论文章
 		comment, err = model.AddComment(db, auth.ID, req.Type, req.TopicId, req.Content, isReview)
 	} else { // 回复评论
-		comment, err = model.ReplyComment(db, auth.ID, req.ReplyUserId, req.ParentId, req.Content, isReview)
+		comment, err = model.ReplyComment(db, auth.ID, req.ParentId, req.Content, isReview)
+	}
+	if errors.Is(err, model.ErrCommentTooDeep) {
+		ReturnError(c, global.ErrCommentTooDeep, nil)
+		return
 	}
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
+	if comment.Mentions, err = model.SaveMentions(db, model.MENTION_COMMENT, comment.ID, users); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
+	// 不需要审核的评论直接通知, 需要审核的评论在审核通过后通知
+	notifyComment(db, GetRDB(c), comment)
 	ReturnSuccess(c, comment)
 }
 
+type FUpdateCommentReq struct {
+	Content string `json:"content" binding:"required,max=5000"` // Markdown
+}
+
+// commentEditTime 评论发布后允许编辑的时间, 未配置时默认 10 分钟
+const commentEditTime = 10
+
+// UpdateComment 作者编辑自己的评论
+// 只能在发布后一段时间内编辑, 每次编辑都会保存编辑前的内容到编辑历史
+func (*Front) UpdateComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	var req FUpdateCommentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	db := GetDB(c)
+
+	comment, ok := getOwnComment(c, id, auth.ID)
+	if !ok {
+		return
+	}
+
+	editTime := model.GetConfigInt(db, global.CONFIG_COMMENT_EDIT_TIME, commentEditTime)
+	if time.Since(comment.CreatedAt) > time.Duration(editTime)*time.Minute {
+		ReturnError(c, global.ErrCommentEditExpire, nil)
+		return
+	}
+
+	// 编辑后的评论与新评论一样需要重新审核
+	isReview := model.GetConfigBool(db, global.CONFIG_IS_COMMENT_REVIEW)
+	content, review, ok := filterSensitive(c, req.Content, true)
+	if !ok {
+		return
+	}
+	req.Content = content
+	if review {
+		isReview = false
+	}
+	// 与新评论一样进行垃圾内容检测, 防止先发布正常内容再编辑为垃圾内容
+	suspect, ok := checkSpamEdit(c, spam.KIND_COMMENT, req.Content, comment.Content, auth.ID)
+	if !ok {
+		return
+	}
+	if suspect {
+		isReview = false
+	}
+	users, ok := resolveMentions(c, req.Content)
+	if !ok {
+		return
+	}
+	if err := model.UpdateCommentContent(db, comment, req.Content, isReview); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	// 编辑只更新 @ 的记录, 不会重新发送通知
+	if comment.Mentions, err = model.SaveMentions(db, model.MENTION_COMMENT, comment.ID, users); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, comment)
+}
+
+// DeleteComment 作者删除自己的评论
+// 软删除: 评论树中保留 "该评论已删除" 的占位, 其下的回复仍然正常展示
+func (*Front) DeleteComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	if _, ok := getOwnComment(c, id, auth.ID); !ok {
+		return
+	}
+
+	if err := model.SoftDeleteComment(GetDB(c), id); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, nil)
+}
+
+// getOwnComment 获取当前用户自己的、未删除的评论, 校验失败时直接返回错误响应
+func getOwnComment(c *gin.Context, id, userId int) (*model.Comment, bool) {
+	comment, err := model.GetCommentById(GetDB(c), id)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return nil, false
+	}
+	if comment.UserId != userId {
+		ReturnError(c, global.ErrCommentNotOwner, nil)
+		return nil, false
+	}
+	if comment.IsDelete {
+		ReturnError(c, global.ErrCommentDeleted, nil)
+		return nil, false
+	}
+	return comment, true
+}
+
 // GetReplyListByCommentId 根据 [评论id] 获取 [回复列表]
+// 返回该评论下所有层级的回复, 按评论树先序遍历排序, 使用游标分页
 func (*Front) GetReplyListByCommentId(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("comment_id"))
 	if err != nil {
@@ -378,7 +542,7 @@ func (*Front) GetReplyListByCommentId(c *gin.Context) {
 		return
 	}
 
-	var query PageQuery
+	var query FReplyQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
 		ReturnError(c, global.ErrRequest, err)
 		return
@@ -387,24 +551,20 @@ func (*Front) GetReplyListByCommentId(c *gin.Context) {
 	db := GetDB(c)
 	rdb := GetRDB(c)
 
-	replyList, err := model.GetCommentReplyList(db, id, query.Page, query.Size)
+	data, next, err := model.GetCommentReplyList(db, id, query.Cursor, query.Size, commentViewer(c))
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
 
 	likeCountMap := rdb.HGetAll(rctx, global.COMMENT_LIKE_COUNT).Val()
+	fillCommentLikeCount(data, likeCountMap)
+	fillCommentReactions(c, data)
 
-	data := make([]model.CommentVO, 0)
-	for _, reply := range replyList {
-		like, _ := strconv.Atoi(likeCountMap[strconv.Itoa(reply.ID)])
-		data = append(data, model.CommentVO{
-			Comment:   reply,
-			LikeCount: like,
-		})
-	}
-	ReturnSuccess(c, data)
-
+	ReturnSuccess(c, CursorResult[model.CommentVO]{
+		List: data,
+		Next: next,
+	})
 }
 
 // LikeComment 点赞评论
@@ -420,22 +580,67 @@ func (*Front) LikeComment(c *gin.Context) {
 
 	// 一个用户对应一个 redis set
 	commentLikeUserKey := global.COMMENT_USER_LIKE_SET + strconv.Itoa(auth.ID)
-	// 该评论已经被记录过, 再点赞就是取消点赞
-	if rdb.SIsMember(rctx, commentLikeUserKey, id).Val() {
-		rdb.SRem(rctx, commentLikeUserKey, id)
-		rdb.HIncrBy(rctx, global.COMMENT_LIKE_COUNT, strconv.Itoa(id), -1)
-	} else { // 未被记录过, 则是增加点赞
-		rdb.SAdd(rctx, commentLikeUserKey, id)
-		rdb.HIncrBy(rctx, global.COMMENT_LIKE_COUNT, strconv.Itoa(id), 1)
+	// 根据 SADD/SREM 的返回值判断是否真的修改了集合, 并发的重复点击只计数一次
+	delta := 1
+	added, err := rdb.SAdd(rctx, commentLikeUserKey, id).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	if added == 0 { // 已经点赞过, 再点赞就是取消点赞
+		removed, err := rdb.SRem(rctx, commentLikeUserKey, id).Result()
+		if err != nil {
+			ReturnError(c, global.ErrRedisOp, err)
+			return
+		}
+		if removed == 0 { // 并发的请求已经取消了点赞
+			ReturnSuccess(c, nil)
+			return
+		}
+		delta = -1
+	}
+	rdb.HIncrBy(rctx, global.COMMENT_LIKE_COUNT, strconv.Itoa(id), int64(delta))
+
+	// 同步到数据库, 用于评论列表按点赞数排序
+	if err := model.UpdateCommentLikeCount(GetDB(c), id, delta); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
 	}
 
 	ReturnSuccess(c, nil)
 }
 
-// GetMessageList 查询消息列表
+// SyncCommentLikes 将 Redis 中的评论点赞数同步到数据库 (comment.like_count), 只在首次启动时执行一次
+// like_count 用于评论列表按点赞数排序, 新增该字段之前的点赞只记录在 Redis 中
+func SyncCommentLikes(db *gorm.DB, rdb *redis.Client) error {
+	first, err := rdb.SetNX(rctx, global.COMMENT_LIKE_SYNCED, true, 0).Result()
+	if err != nil || !first {
+		return err
+	}
+
+	vals, err := rdb.HGetAll(rctx, global.COMMENT_LIKE_COUNT).Result()
+	if err != nil {
+		rdb.Del(rctx, global.COMMENT_LIKE_SYNCED)
+		return err
+	}
+	counts := make(map[int]int, len(vals))
+	for k, v := range vals {
+		id, err1 := strconv.Atoi(k)
+		count, err2 := strconv.Atoi(v)
+		if err1 == nil && err2 == nil {
+			counts[id] = count
+		}
+	}
+	if err := model.SyncCommentLikeCounts(db, counts); err != nil {
+		rdb.Del(rctx, global.COMMENT_LIKE_SYNCED)
+		return err
+	}
+	return nil
+}
+
+// GetMessageList 查询消息列表, 游客可以看到自己尚未审核的留言
 func (*Front) GetMessageList(c *gin.Context) {
-	isReview := true
-	list, _, err := model.GetMessageList(GetDB(c), 1, 1000, "", &isReview)
+	list, err := model.GetFrontMessageList(GetDB(c), guestId(c, false))
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
@@ -458,26 +663,245 @@ func (*Front) SaveMessage(c *gin.Context) {
 		return
 	}
 
-	req.Content = template.HTMLEscapeString(req.Content)
 	auth, _ := CurrentUserAuth(c)
 	db := GetDB(c)
 
 	ipAddress := utils.IP.GetIpAddress(c)
 	ipSource := utils.IP.GetIpSource(ipAddress)
-	isReview := model.GetConfigBool(db, global.CONFIG_IS_COMMENT_REVIEW)
+	isReview := model.GetConfigBool(db, global.CONFIG_IS_MESSAGE_REVIEW)
+
+	content, review, ok := filterSensitive(c, req.Content, true)
+	if !ok {
+		return
+	}
+	req.Content = content
+	if review {
+		isReview = false
+	}
 
+	// 疑似垃圾内容转为待审核
+	suspect, ok := checkSpam(c, spam.KIND_MESSAGE, req.Content, auth.ID)
+	if !ok {
+		return
+	}
+	if suspect {
+		isReview = false
+	}
+
+	users, ok := resolveMentions(c, req.Content)
+	if !ok {
+		return
+	}
+
+	// 敏感词、垃圾内容和 @ 都基于原文处理, 保存前最后转义
+	req.Content = template.HTMLEscapeString(req.Content)
 	info := auth.UserInfo
-	message, err := model.SaveMessage(db, info.Nickname, info.Nickname, req.Content, ipAddress, ipSource, req.Speed, isReview)
+	message, err := model.SaveMessage(db, auth.ID, info.Nickname, info.Avatar, req.Content, ipAddress, ipSource, req.Speed, isReview)
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
+	if message.Mentions, err = model.SaveMentions(db, model.MENTION_MESSAGE, message.ID, users); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
+	notifyMessage(db, GetRDB(c), message)
 	ReturnSuccess(c, message)
 }
 
+// 游客评论与留言 ------------------------------------------------------------
+
+// FGuestReq 游客信息与验证码
+type FGuestReq struct {
+	Nickname  string `json:"nickname" binding:"required,max=50"`
+	Email     string `json:"email" binding:"required,email,max=100"`
+	Website   string `json:"website" binding:"omitempty,http_url,max=255"`
+	CaptchaId string `json:"captcha_id" binding:"required"`
+	Captcha   string `json:"captcha" binding:"required"`
+}
+
+type FGuestCommentReq struct {
+	FGuestReq
+	TopicId  int    `json:"topic_id"`
+	ParentId int    `json:"parent_id"`
+	Type     int    `json:"type" binding:"required,min=1,max=3"`
+	Content  string `json:"content" binding:"required,max=5000"` // Markdown
+}
+
+type FGuestMessageReq struct {
+	FGuestReq
+	Content string `json:"content" binding:"required,max=255"`
+	Speed   int    `json:"speed"`
+}
+
+type CaptchaVO struct {
+	CaptchaId string `json:"captcha_id"`
+	Image     string `json:"image"` // data URI, 可以直接作为 <img> 的 src
+}
+
+// GetCaptcha 获取图片验证码, 答案保存在 Redis 中, 只能校验一次
+func (*Front) GetCaptcha(c *gin.Context) {
+	question, answer := utils.NewMathCaptcha(global.GetConfig().Captcha.Level)
+	id := utils.RandomToken(16)
+
+	expire := time.Duration(global.GetConfig().Captcha.ExpireTime) * time.Second
+	if err := GetRDB(c).Set(rctx, global.CAPTCHA+id, answer, expire).Err(); err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+
+	ReturnSuccess(c, CaptchaVO{
+		CaptchaId: id,
+		Image:     utils.CaptchaDataURI(utils.CaptchaSVG(question)),
+	})
+}
+
+// SaveGuestComment 游客评论 (需要开启游客评论), 游客评论一律进入审核
+func (*Front) SaveGuestComment(c *gin.Context) {
+	var req FGuestCommentReq
+	if !bindGuestReq(c, &req, &req.FGuestReq) {
+		return
+	}
+
+	// 游客评论一律进入审核, 只需要拦截直接拒绝的内容
+	content, _, ok := filterSensitive(c, req.Content, true)
+	if !ok {
+		return
+	}
+	req.Content = content
+	if _, ok := checkSpam(c, spam.KIND_COMMENT, req.Content, 0); !ok {
+		return
+	}
+	users, ok := resolveMentions(c, req.Content)
+	if !ok {
+		return
+	}
+
+	db := GetDB(c)
+	comment, err := model.AddGuestComment(db, newGuest(c, req.FGuestReq), req.Type, req.TopicId, req.ParentId, req.Content)
+	if errors.Is(err, model.ErrCommentTooDeep) {
+		ReturnError(c, global.ErrCommentTooDeep, nil)
+		return
+	}
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	// 审核通过后通知
+	if comment.Mentions, err = model.SaveMentions(db, model.MENTION_COMMENT, comment.ID, users); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, comment)
+}
+
+// SaveGuestMessage 游客留言 (需要开启游客评论), 游客留言一律进入审核
+func (*Front) SaveGuestMessage(c *gin.Context) {
+	var req FGuestMessageReq
+	if !bindGuestReq(c, &req, &req.FGuestReq) {
+		return
+	}
+
+	content, _, ok := filterSensitive(c, req.Content, true)
+	if !ok {
+		return
+	}
+	req.Content = content
+	if _, ok := checkSpam(c, spam.KIND_MESSAGE, req.Content, 0); !ok {
+		return
+	}
+	users, ok := resolveMentions(c, req.Content)
+	if !ok {
+		return
+	}
+
+	ipAddress := utils.IP.GetIpAddress(c)
+	ipSource := utils.IP.GetIpSource(ipAddress)
+
+	// 敏感词、垃圾内容和 @ 都基于原文处理, 保存前最后转义
+	req.Content = template.HTMLEscapeString(req.Content)
+	db := GetDB(c)
+	message, err := model.SaveGuestMessage(db, newGuest(c, req.FGuestReq), req.Content, ipAddress, ipSource, req.Speed)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	// 审核通过后通知
+	if message.Mentions, err = model.SaveMentions(db, model.MENTION_MESSAGE, message.ID, users); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, message)
+}
+
+// bindGuestReq 校验是否开启游客评论, 绑定请求参数并校验验证码, 失败时直接返回错误响应
+func bindGuestReq(c *gin.Context, req any, guest *FGuestReq) bool {
+	if !model.GetConfigBool(GetDB(c), global.CONFIG_IS_GUEST_COMMENT) {
+		ReturnError(c, global.ErrGuestDisabled, nil)
+		return false
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return false
+	}
+	// 验证码只能使用一次, 无论正确与否都删除
+	answer, err := GetRDB(c).GetDel(rctx, global.CAPTCHA+guest.CaptchaId).Result()
+	if err != nil || answer != strings.TrimSpace(guest.Captcha) {
+		ReturnError(c, global.ErrCaptcha, nil)
+		return false
+	}
+	// 昵称没有审核流程
+	nickname, _, ok := filterSensitive(c, guest.Nickname, false)
+	if !ok {
+		return false
+	}
+	guest.Nickname = nickname
+	return true
+}
+
+// newGuest 根据请求生成游客信息, 头像由邮箱生成 (Gravatar)
+func newGuest(c *gin.Context, req FGuestReq) model.Guest {
+	return model.Guest{
+		Nickname: template.HTMLEscapeString(req.Nickname),
+		Email:    strings.TrimSpace(req.Email),
+		Website:  req.Website,
+		Avatar:   utils.Gravatar(req.Email),
+		GuestId:  guestId(c, true),
+	}
+}
+
+// guestIdMaxAge 游客标识 cookie 有效期 (seconds)
+const guestIdMaxAge = 365 * 24 * 60 * 60
+
+// guestId 获取游客标识 cookie, create 为 true 且不存在时生成新的标识
+func guestId(c *gin.Context, create bool) string {
+	id, err := c.Cookie(global.COOKIE_GUEST_ID)
+	if err == nil && id != "" {
+		return id
+	}
+	if !create {
+		return ""
+	}
+	id = utils.RandomToken(16)
+	secure := strings.HasPrefix(global.GetConfig().Server.PublicURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(global.COOKIE_GUEST_ID, id, guestIdMaxAge, "/", "", secure, true)
+	return id
+}
+
+// commentViewer 当前浏览评论的用户 (登录用户或游客), 用于展示自己尚未审核的评论
+func commentViewer(c *gin.Context) model.CommentViewer {
+	viewer := model.CommentViewer{GuestId: guestId(c, false)}
+	if auth, err := CurrentUserAuth(c); err == nil {
+		viewer.UserId = auth.ID
+	}
+	return viewer
+}
+
 // GetLinkList 获取友链列表
 func (*Front) GetLinkList(c *gin.Context) {
-	list, _, err := model.GetLinkList(GetDB(c), 1, 1000, "")
+	list, err := model.GetReviewedLinkList(GetDB(c))
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
@@ -485,6 +909,39 @@ func (*Front) GetLinkList(c *gin.Context) {
 	ReturnSuccess(c, list)
 }
 
+type FApplyLinkReq struct {
+	Name    string `json:"name" binding:"required,max=50"`
+	Avatar  string `json:"avatar" binding:"omitempty,http_url,max=255"`
+	Address string `json:"address" binding:"required,http_url,max=255"`
+	Intro   string `json:"intro" binding:"max=255"`
+}
+
+// ApplyLink 申请友链, 在审核队列中审核通过后展示
+func (*Front) ApplyLink(c *gin.Context) {
+	var req FApplyLinkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	name, _, ok := filterSensitive(c, req.Name, true)
+	if !ok {
+		return
+	}
+	intro, _, ok := filterSensitive(c, req.Intro, true)
+	if !ok {
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	link, err := model.ApplyLink(GetDB(c), auth.ID, name, req.Avatar, req.Address, intro)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, link)
+}
+
 // LikeArticle 点赞文章
 // 需要记录某个用户已经对某篇文章点过赞, 防止重复点赞
 func (*Front) LikeArticle(c *gin.Context) {
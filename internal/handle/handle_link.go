@@ -67,6 +67,17 @@ func (*Link) SaveOrUpdate(c *gin.Context) {
 		return
 	}
 
+	// 友链名称与简介展示在前台, 同样需要过滤敏感词
+	name, _, ok := filterSensitive(c, req.Name, false)
+	if !ok {
+		return
+	}
+	intro, _, ok := filterSensitive(c, req.Intro, false)
+	if !ok {
+		return
+	}
+	req.Name, req.Intro = name, intro
+
 	link, err := model.SaveOrUpdateLink(GetDB(c), req.ID, req.Name, req.Avatar, req.Address, req.Intro)
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
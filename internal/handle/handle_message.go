@@ -3,6 +3,7 @@ package handle
 import (
 	"gin-blog-server/internal/global"
 	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils/spam"
 	"github.com/gin-gonic/gin"
 )
 
@@ -54,6 +55,7 @@ func (*Message) GetList(c *gin.Context) {
 // @Description 根据 ID 数组删除留言
 // @Tags Category
 // @Param ids body []int true "留言 ID 数组"
+// @Param spam query bool false "是否标记为垃圾内容 (作为分类器的训练数据)"
 // @Accept json
 // @Produce json
 // @Success 0 {object} Response[int]
@@ -66,11 +68,27 @@ func (*Message) Delete(c *gin.Context) {
 		return
 	}
 
-	rows, err := model.DeleteMessages(GetDB(c), ids)
+	db := GetDB(c)
+	// 标记为垃圾内容时, 删除前取出留言内容作为训练数据; 普通删除不参与训练
+	var list []model.Message
+	if isSpam := c.Query("spam") == "true"; isSpam {
+		var err error
+		if list, err = model.GetMessagesByIds(db, ids); err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+	}
+
+	rows, err := model.DeleteMessages(db, ids)
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
+
+	rdb := GetRDB(c)
+	for _, message := range list {
+		trainSpam(rdb, spam.KIND_MESSAGE, message.ID, message.Content, true)
+	}
 	ReturnSuccess(c, rows)
 }
 
@@ -91,10 +109,25 @@ func (*Message) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	rows, err := model.UpdateMessagesReview(GetDB(c), req.Ids, req.IsReview)
+	db := GetDB(c)
+	rows, err := model.UpdateMessagesReview(db, req.Ids, req.IsReview)
 	if err != nil {
 		ReturnError(c, global.ErrDbOp, err)
 		return
 	}
+
+	// 审核通过的留言作为正常内容的训练数据, 并通知留言中 @ 的用户
+	if req.IsReview {
+		list, err := model.GetMessagesByIds(db, req.Ids)
+		if err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		rdb := GetRDB(c)
+		for _, message := range list {
+			trainSpam(rdb, spam.KIND_MESSAGE, message.ID, message.Content, false)
+			notifyMessage(db, rdb, &message)
+		}
+	}
 	ReturnSuccess(c, rows)
 }
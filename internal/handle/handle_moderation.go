@@ -0,0 +1,186 @@
+package handle
+
+import (
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils/spam"
+	"github.com/gin-gonic/gin"
+)
+
+type Moderation struct{}
+
+// ModerationQuery 审核队列查询条件
+type ModerationQuery struct {
+	PageQuery
+	Type   string `form:"type" binding:"omitempty,oneof=comment message link"`        // 为空时查询全部类型
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"` // 为空时查询全部状态
+}
+
+// GetList 审核队列
+// @Summary 审核队列
+// @Description 评论、留言、友链申请的统一审核队列
+// @Tags Moderation
+// @Param type query string false "类型: comment | message | link"
+// @Param status query string false "状态: pending | approved | rejected"
+// @Param keyword query string false "搜索关键字 (内容/昵称)"
+// @Param page_num query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[PageResult[model.ModerationItem]]
+// @Security ApiKeyAuth
+// @Router /moderation/list [get]
+func (*Moderation) GetList(c *gin.Context) {
+	var query ModerationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	list, total, err := model.GetModerationList(GetDB(c), query.Page, query.Size, query.Type, query.Status, query.Keyword)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, PageResult[model.ModerationItem]{
+		Total: int(total),
+		List:  list,
+		Size:  query.Size,
+		Page:  query.Page,
+	})
+}
+
+// ModerateReq 批量审核
+type ModerateReq struct {
+	Type   string `json:"type" binding:"required,oneof=comment message link"`
+	Ids    []int  `json:"ids" binding:"required,min=1"`
+	Action string `json:"action" binding:"required,oneof=approve reject delete spam"`
+	Reason string `json:"reason" binding:"max=255"`
+	Notify bool   `json:"notify"` // 是否通知作者
+}
+
+// Moderate 批量审核: 通过、拒绝、删除或标记为垃圾内容
+// @Summary 批量审核
+// @Description 批量通过、拒绝、删除或标记为垃圾内容 (删除并训练分类器) 评论/留言/友链申请, 记录审核原因和审核人, 可选通知作者
+// @Tags Moderation
+// @Param form body ModerateReq true "审核操作"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[int]
+// @Security ApiKeyAuth
+// @Router /moderation [put]
+func (*Moderation) Moderate(c *gin.Context) {
+	var req ModerateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	targets, err := model.GetModerationTargets(db, req.Type, req.Ids)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	moderator := auth.Username
+	if auth.UserInfo != nil {
+		moderator = auth.UserInfo.Nickname
+	}
+	// 只有状态改变的对象需要训练分类器和发送通知, 重复审核不会重复通知
+	changed, err := model.Moderate(db, req.Type, targets, req.Action, req.Reason, auth.ID, moderator)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if len(changed) == 0 {
+		ReturnSuccess(c, 0)
+		return
+	}
+	ids := make([]int, len(changed))
+	for i, t := range changed {
+		ids[i] = t.ID
+	}
+
+	rdb := GetRDB(c)
+	switch {
+	// 通过的评论/留言作为正常内容的训练数据, 并通知其中 @ 的用户
+	case req.Action == model.MODERATION_APPROVE && req.Type == model.MODERATION_COMMENT:
+		list, err := model.GetCommentsByIds(db, ids)
+		if err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		for _, comment := range list {
+			trainSpam(rdb, spam.KIND_COMMENT, comment.ID, comment.Content, false)
+			notifyComment(db, rdb, &comment)
+		}
+	case req.Action == model.MODERATION_APPROVE && req.Type == model.MODERATION_MESSAGE:
+		list, err := model.GetMessagesByIds(db, ids)
+		if err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		for _, message := range list {
+			trainSpam(rdb, spam.KIND_MESSAGE, message.ID, message.Content, false)
+			notifyMessage(db, rdb, &message)
+		}
+	// 标记为垃圾内容的评论/留言作为垃圾内容的训练数据, 普通删除不参与训练
+	case req.Action == model.MODERATION_SPAM && req.Type != model.MODERATION_LINK:
+		kind := spam.KIND_COMMENT
+		if req.Type == model.MODERATION_MESSAGE {
+			kind = spam.KIND_MESSAGE
+		}
+		for _, t := range changed {
+			trainSpam(rdb, kind, t.ID, t.Content, true)
+		}
+	}
+
+	if req.Notify {
+		for _, t := range changed {
+			notifyModeration(db, rdb, req.Type, t, req.Action, req.Reason)
+		}
+	}
+	ReturnSuccess(c, len(changed))
+}
+
+// ModerationLogQuery 审核记录查询条件
+type ModerationLogQuery struct {
+	PageQuery
+	Type     string `form:"type"`
+	TargetId int    `form:"target_id"`
+}
+
+// GetLogList 审核记录
+// @Summary 审核记录
+// @Description 查询审核记录, 可按类型和对象筛选
+// @Tags Moderation
+// @Param type query string false "类型: comment | message | link"
+// @Param target_id query int false "审核对象 id"
+// @Param page_num query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[PageResult[model.ModerationLog]]
+// @Security ApiKeyAuth
+// @Router /moderation/log [get]
+func (*Moderation) GetLogList(c *gin.Context) {
+	var query ModerationLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	list, total, err := model.GetModerationLogList(GetDB(c), query.Page, query.Size, query.Type, query.TargetId)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, PageResult[model.ModerationLog]{
+		Total: int(total),
+		List:  list,
+		Size:  query.Size,
+		Page:  query.Page,
+	})
+}
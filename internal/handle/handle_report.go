@@ -0,0 +1,210 @@
+package handle
+
+import (
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+)
+
+type Report struct{}
+
+// 被举报多少次后自动隐藏, 未配置时的默认值
+const defaultReportThreshold = 3
+
+type FReportReq struct {
+	TargetType string `json:"target_type" binding:"required,oneof=comment message"`
+	TargetId   int    `json:"target_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required,oneof=spam abuse porn illegal other"`
+	Detail     string `json:"detail" binding:"max=255"`
+}
+
+// ReportContent 读者举报评论/留言, 每个用户对同一内容只能举报一次
+// 未处理的举报次数达到阈值时自动隐藏内容 (转为待审核), 等待审核人员处理
+func (*Front) ReportContent(c *gin.Context) {
+	var req FReportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	// 只能举报存在且对外可见的内容
+	db := GetDB(c)
+	switch req.TargetType {
+	case model.MODERATION_COMMENT:
+		comment, err := model.GetCommentById(db, req.TargetId)
+		if err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		if comment.IsDelete || !comment.IsReview {
+			ReturnError(c, global.ErrCommentDeleted, nil)
+			return
+		}
+	case model.MODERATION_MESSAGE:
+		list, err := model.GetMessagesByIds(db, []int{req.TargetId})
+		if err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		if len(list) == 0 || !list[0].IsReview {
+			ReturnError(c, global.ErrRequest, nil)
+			return
+		}
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	reported, err := model.HasReported(db, req.TargetType, req.TargetId, auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if reported {
+		ReturnError(c, global.ErrReportDuplicate, nil)
+		return
+	}
+
+	created, count, err := model.AddReport(db, &model.Report{
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		UserId:     auth.ID,
+		Reason:     req.Reason,
+		Detail:     req.Detail,
+	})
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if !created { // 并发的重复举报
+		ReturnError(c, global.ErrReportDuplicate, nil)
+		return
+	}
+
+	threshold := model.GetConfigInt(db, global.CONFIG_REPORT_THRESHOLD, defaultReportThreshold)
+	if threshold > 0 && count >= int64(threshold) {
+		if err := model.HideReportedTarget(db, req.TargetType, req.TargetId); err != nil {
+			slog.Error("隐藏被举报内容失败", "type", req.TargetType, "id", req.TargetId, "err", err)
+		}
+	}
+	ReturnSuccess(c, nil)
+}
+
+// ReportQuery 举报列表查询条件
+type ReportQuery struct {
+	PageQuery
+	TargetType string `form:"target_type"`
+	IsHandled  bool   `form:"is_handled"`
+}
+
+// GetList 举报列表 (按被举报内容汇总)
+// @Summary 举报列表
+// @Description 按被举报内容汇总举报次数及原因, 按举报次数排序
+// @Tags Report
+// @Param target_type query string false "类型: comment | message"
+// @Param is_handled query bool false "是否已处理"
+// @Param page_num query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[PageResult[model.ReportSummary]]
+// @Security ApiKeyAuth
+// @Router /report/list [get]
+func (*Report) GetList(c *gin.Context) {
+	var query ReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	list, total, err := model.GetReportSummaryList(GetDB(c), query.Page, query.Size, query.TargetType, query.IsHandled)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, PageResult[model.ReportSummary]{
+		Total: int(total),
+		List:  list,
+		Size:  query.Size,
+		Page:  query.Page,
+	})
+}
+
+// ReportDetailQuery 举报明细查询条件
+type ReportDetailQuery struct {
+	TargetType string `form:"target_type" binding:"required"`
+	TargetId   int    `form:"target_id" binding:"required"`
+}
+
+// GetDetail 某个内容的举报明细
+// @Summary 举报明细
+// @Description 某个被举报内容的全部举报记录
+// @Tags Report
+// @Param target_type query string true "类型: comment | message"
+// @Param target_id query int true "被举报内容 id"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[[]model.Report]
+// @Security ApiKeyAuth
+// @Router /report/detail [get]
+func (*Report) GetDetail(c *gin.Context) {
+	var query ReportDetailQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	list, err := model.GetReportList(GetDB(c), query.TargetType, query.TargetId)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, list)
+}
+
+// DismissReportReq 驳回举报
+type DismissReportReq struct {
+	TargetType string `json:"target_type" binding:"required,oneof=comment message"`
+	Ids        []int  `json:"ids" binding:"required,min=1"` // 被举报内容 id
+	Reason     string `json:"reason" binding:"max=255"`
+}
+
+// Dismiss 驳回举报: 内容没有问题, 恢复展示并将举报标记为已处理
+// 需要删除或拒绝的内容通过审核队列处理 (/moderation), 同样会将举报标记为已处理
+// @Summary 驳回举报
+// @Description 驳回举报, 恢复被自动隐藏的内容, 记录审核日志
+// @Tags Report
+// @Param form body DismissReportReq true "驳回举报"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[int]
+// @Security ApiKeyAuth
+// @Router /report/dismiss [put]
+func (*Report) Dismiss(c *gin.Context) {
+	var req DismissReportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	targets, err := model.GetModerationTargets(db, req.TargetType, req.Ids)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
+	if req.Reason == "" {
+		req.Reason = "举报不成立"
+	}
+	auth, _ := CurrentUserAuth(c)
+	moderator := auth.Username
+	if auth.UserInfo != nil {
+		moderator = auth.UserInfo.Nickname
+	}
+	changed, err := model.Moderate(db, req.TargetType, targets, model.MODERATION_APPROVE, req.Reason, auth.ID, moderator)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, len(changed))
+}
@@ -16,6 +16,7 @@ type AddOrEditRoleReq struct {
 	Name        string `json:"name" binding:"required"`
 	Label       string `json:"label" binding:"required"`
 	IsDisable   bool   `json:"is_disable"`
+	Require2FA  bool   `json:"require_2fa"`  // 是否要求开启两步验证
 	ResourceIds []int  `json:"resource_ids"` // 资源 id 列表
 	MenuIds     []int  `json:"menu_ids"`     // 菜单 id 列表
 }
@@ -94,7 +95,7 @@ func (*Role) SaveOrUpdate(c *gin.Context) {
 			return
 		}
 	} else {
-		err := model.UpdateRole(db, req.ID, req.Name, req.Label, req.IsDisable, req.ResourceIds, req.MenuIds)
+		err := model.UpdateRole(db, req.ID, req.Name, req.Label, req.IsDisable, req.Require2FA, req.ResourceIds, req.MenuIds)
 		if err != nil {
 			ReturnError(c, global.ErrDbOp, err)
 			return
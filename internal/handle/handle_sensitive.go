@@ -0,0 +1,120 @@
+package handle
+
+import (
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils/sensitive"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Sensitive struct{}
+
+// GetList 敏感词词库列表
+func (*Sensitive) GetList(c *gin.Context) {
+	var query PageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	data, total, err := model.GetSensitiveList(GetDB(c), query.Page, query.Size, query.Keyword)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
+	ReturnSuccess(c, PageResult[model.SensitiveList]{
+		Total: int(total),
+		List:  data,
+		Size:  query.Size,
+		Page:  query.Page,
+	})
+}
+
+// AddOrEditSensitiveReq 添加或修改敏感词词库
+type AddOrEditSensitiveReq struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name" binding:"required"`
+	Action    int    `json:"action" binding:"required,min=1,max=3"` // 1.替换为* 2.转人工审核 3.直接拒绝
+	Words     string `json:"words"`                                 // 每行一个敏感词
+	IsDisable bool   `json:"is_disable"`
+}
+
+// SaveOrUpdate 添加或修改敏感词词库, 修改后立即重新加载词库
+func (*Sensitive) SaveOrUpdate(c *gin.Context) {
+	var req AddOrEditSensitiveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	list, err := model.SaveOrUpdateSensitiveList(db, req.ID, req.Name, req.Action, req.Words, req.IsDisable)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if err := LoadSensitiveWords(db); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, list)
+}
+
+// Delete 删除敏感词词库（批量）, 删除后立即重新加载词库
+func (*Sensitive) Delete(c *gin.Context) {
+	var ids []int
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	rows, err := model.DeleteSensitiveLists(db, ids)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if err := LoadSensitiveWords(db); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, rows)
+}
+
+// Reload 从数据库重新加载词库 (例如直接修改了数据库, 或多实例部署时同步其他实例的修改)
+func (*Sensitive) Reload(c *gin.Context) {
+	if err := LoadSensitiveWords(GetDB(c)); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, nil)
+}
+
+// LoadSensitiveWords 从数据库加载敏感词, 替换当前使用的词库
+func LoadSensitiveWords(db *gorm.DB) error {
+	words, err := model.GetSensitiveWords(db)
+	if err != nil {
+		return err
+	}
+	sensitive.Load(words)
+	return nil
+}
+
+// filterSensitive 过滤用户提交的内容
+// 命中 "直接拒绝" 时返回错误响应 (ok = false); 命中 "转人工审核" 时 review = true; 命中 "替换为*" 的敏感词已在 text 中替换
+// allowReview 为 false 时 (例如昵称这类没有审核流程的内容), "转人工审核" 按 "直接拒绝" 处理
+func filterSensitive(c *gin.Context, content string, allowReview bool) (text string, review bool, ok bool) {
+	result := sensitive.Filter(content)
+	switch {
+	case result.Action == sensitive.ACTION_REJECT,
+		result.Action == sensitive.ACTION_REVIEW && !allowReview:
+		ReturnError(c, global.ErrSensitive, nil)
+		return "", false, false
+	case result.Action == sensitive.ACTION_REVIEW:
+		return result.Text, true, true
+	default:
+		return result.Text, false, true
+	}
+}
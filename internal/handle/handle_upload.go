@@ -9,21 +9,32 @@ import (
 	"github.com/gin-gonic/gin"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Upload struct{}
 
+// PrivateFileVO 私有文件上传结果
+type PrivateFileVO struct {
+	Key       string    `json:"key"`        // 文件 key, 用于重新生成签名链接
+	URL       string    `json:"url"`        // 签名访问链接
+	ExpiresAt time.Time `json:"expires_at"` // 签名链接过期时间
+}
+
 // UploadFile 上传文件
+// 文件按内容 SHA-256 去重存储: 上传相同内容时不再重复保存, 直接返回已有的访问路径
 // @Summary 上传文件
-// @Description 上传文件
+// @Description 上传文件, private=true 时保存为私有文件, 只能通过签名链接访问
 // @Tags upload
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "文件"
+// @Param private formData bool false "是否为私有文件"
 // @Success 0 {object} Response[string]
 // @Router /upload/file [post]
 func (*Upload) UploadFile(c *gin.Context) {
@@ -33,19 +44,188 @@ func (*Upload) UploadFile(c *gin.Context) {
 		ReturnError(c, global.ErrRequest, err)
 		return
 	}
-	// 获取 oss 对象存储接口
-	oss := upload.NewOSS()
-	// 实现图片上传
-	filePath, _, err := oss.UploadFile(fileHeader)
+	hash, err := upload.Hash(fileHeader)
+	if err != nil {
+		ReturnError(c, global.ErrFileReceive, err)
+		return
+	}
+	private := c.PostForm("private") == "true"
+	db := GetDB(c)
+
+	// 相同内容的文件已经存在: 引用计数 +1, 直接返回
+	media, err := model.AcquireMedia(db, hash, private)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if media == nil {
+		media, err = storeMedia(c, fileHeader, hash, private)
+		if err != nil {
+			ReturnError(c, global.ErrFileUpload, err)
+			return
+		}
+	}
+
+	if private {
+		url, expiresAt := upload.SignURL(media.Key)
+		ReturnSuccess(c, PrivateFileVO{Key: media.Key, URL: url, ExpiresAt: expiresAt})
+		return
+	}
+	ReturnSuccess(c, media.Url)
+}
+
+// storeMedia 保存新文件并新增文件记录
+// 私有文件统一保存在本地私有目录, 不经过对象存储
+func storeMedia(c *gin.Context, fileHeader *multipart.FileHeader, hash string, private bool) (*model.Media, error) {
+	key := upload.ContentKey(hash, fileHeader.Filename)
+	media := &model.Media{
+		Hash:    hash,
+		Private: private,
+		Key:     key,
+		Name:    fileHeader.Filename,
+		Size:    fileHeader.Size,
+	}
+	if private {
+		if err := (&upload.Local{}).UploadPrivateFile(fileHeader, key); err != nil {
+			return nil, err
+		}
+		media.OssType = "local"
+	} else {
+		url, err := upload.NewOSS().UploadFile(fileHeader, key)
+		if err != nil {
+			return nil, err
+		}
+		media.Url = url
+		media.OssType = global.GetConfig().Upload.OssType
+	}
+	if auth, err := CurrentUserAuth(c); err == nil {
+		media.UserId = auth.ID
+	}
+	return model.CreateMedia(GetDB(c), media)
+}
+
+// SignFile 为私有文件重新生成签名链接
+// @Summary 生成私有文件签名链接
+// @Description 根据文件 key 生成带过期时间的签名访问链接
+// @Tags upload
+// @Param key query string true "文件 key"
+// @Produce json
+// @Success 0 {object} Response[PrivateFileVO]
+// @Security ApiKeyAuth
+// @Router /upload/sign [get]
+func (*Upload) SignFile(c *gin.Context) {
+	key := c.Query("key")
+	storePath, err := upload.PrivateFilePath(key)
 	if err != nil {
 		ReturnError(c, global.ErrRequest, err)
 		return
 	}
-	ReturnSuccess(c, filePath)
+	if _, err := os.Stat(storePath); err != nil {
+		ReturnError(c, global.ErrFileInfo, err)
+		return
+	}
+	url, expiresAt := upload.SignURL(key)
+	ReturnSuccess(c, PrivateFileVO{Key: key, URL: url, ExpiresAt: expiresAt})
 }
 
+// GetPrivateFile 通过签名链接访问私有文件
+// 签名绑定了文件 key 与过期时间, 校验通过后才返回文件内容
+// @Summary 访问私有文件
+// @Tags upload
+// @Param key path string true "文件 key"
+// @Param expires query int true "过期时间戳"
+// @Param sign query string true "签名"
+// @Router /file/private/{key} [get]
+func (*Upload) GetPrivateFile(c *gin.Context) {
+	key := c.Param("key")
+	err := upload.VerifySign(upload.SignSecret(), key, c.Query("expires"), c.Query("sign"), time.Now())
+	if err != nil {
+		if errors.Is(err, upload.ErrSignExpired) {
+			ReturnError(c, global.ErrFileExpired, err)
+			return
+		}
+		ReturnError(c, global.ErrFileSign, err)
+		return
+	}
+
+	storePath, err := upload.PrivateFilePath(key)
+	if err != nil {
+		ReturnError(c, global.ErrFileSign, err)
+		return
+	}
+	if _, err := os.Stat(storePath); err != nil {
+		ReturnError(c, global.ErrFileOpen, err)
+		return
+	}
+	// 私有文件不允许被公共缓存 (CDN / 代理) 缓存
+	c.Header("Cache-Control", "private, no-store")
+	c.File(storePath)
+}
+
+// DeleteFileReq 删除文件: 公开文件使用访问路径, 私有文件使用文件 key
+type DeleteFileReq struct {
+	Url string `json:"url"`
+	Key string `json:"key"`
+}
+
+// DeleteFile 删除文件
+// 只释放一个引用, 最后一个引用被删除时才真正删除存储的文件
+// @Summary 删除文件
+// @Description 释放文件引用, 引用计数为 0 时删除文件
+// @Tags upload
+// @Param form body DeleteFileReq true "文件路径或 key"
+// @Accept json
+// @Produce json
+// @Success 0 {object} Response[int]
+// @Security ApiKeyAuth
+// @Router /upload [delete]
 func (*Upload) DeleteFile(c *gin.Context) {
+	var req DeleteFileReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	var media *model.Media
+	var err error
+	switch {
+	case req.Key != "":
+		media, err = model.GetMediaByKey(db, req.Key, true)
+	case req.Url != "":
+		media, err = model.GetMediaByUrl(db, req.Url)
+	default:
+		ReturnError(c, global.ErrRequest, "url 和 key 不能同时为空")
+		return
+	}
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
 
+	media, removed, err := model.ReleaseMedia(db, media.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	// 记录删除之后可能又上传了相同内容的文件 (同一个 key), 此时保留存储的文件
+	if removed {
+		if _, err := model.GetMediaByKey(db, media.Key, media.Private); err == nil {
+			removed = false
+		}
+	}
+	if removed {
+		if media.Private {
+			err = (&upload.Local{}).DeletePrivateFile(media.Key)
+		} else {
+			err = upload.NewOSS().DeleteFile(media.Key)
+		}
+		if err != nil {
+			ReturnError(c, global.ErrFileDelete, err)
+			return
+		}
+	}
+	ReturnSuccess(c, media.RefCount)
 }
 
 func (*Upload) DownloadFile(c *gin.Context) {
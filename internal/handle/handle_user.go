@@ -20,7 +20,6 @@ type UpdateCurrentUserReq struct {
 	Avatar   string `json:"avatar"`
 	Intro    string `json:"intro"`
 	Website  string `json:"website"`
-	Email    string `json:"email"`
 }
 
 func (*User) GetInfo(c *gin.Context) {
@@ -32,7 +31,7 @@ func (*User) GetInfo(c *gin.Context) {
 		return
 	}
 
-	userInfoVO := model.UserInfoVO{UserInfo: *user.UserInfo}
+	userInfoVO := model.UserInfoVO{UserInfo: *user.UserInfo, DeleteAt: user.DeleteAt}
 
 	userInfoVO.ArticleLikeSet, err = rdb.SMembers(rctx, global.ARTICLE_USER_LIKE_SET+strconv.Itoa(user.ID)).Result()
 	if err != nil {
@@ -59,6 +58,13 @@ func (*User) UpdateCurrent(c *gin.Context) {
 		return
 	}
 
+	// 昵称没有审核流程, 命中需要审核的敏感词时直接拒绝
+	nickname, _, ok := filterSensitive(c, req.Nickname, false)
+	if !ok {
+		return
+	}
+	req.Nickname = nickname
+
 	auth, _ := CurrentUserAuth(c)
 	err := model.UpdateUserInfo(GetDB(c), auth.UserInfoId, req.Nickname, req.Avatar, req.Intro, req.Website)
 	if err != nil {
@@ -178,12 +184,19 @@ func (*User) UpdateCurrentPassword(c *gin.Context) {
 	uid := auth.ID
 
 	rdb := GetRDB(c)
+	clearPasswordResetTokens(rdb, uid)
 	onlineKey := global.ONLINE_USER + strconv.Itoa(uid)
 	offlineKey := global.OFFLINE_USER + strconv.Itoa(uid)
 
 	rdb.Del(rctx, onlineKey)
 	rdb.Set(rctx, offlineKey, auth, time.Hour)
 
+	// 吊销该用户的全部令牌, 已签发的访问令牌和刷新令牌都不能再使用
+	if err := revokeUserTokens(GetDB(c), rdb, uid); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
 	ReturnSuccess(c, nil)
 }
 
@@ -246,5 +259,11 @@ func (*User) ForceOffline(c *gin.Context) {
 	rdb.Del(rctx, onlineKey)
 	rdb.Set(rctx, offlineKey, auth, time.Hour)
 
+	// 吊销该用户的全部令牌, 已签发的访问令牌和刷新令牌都不能再使用
+	if err := revokeUserTokens(GetDB(c), rdb, uid); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
 	ReturnSuccess(c, "强制离线成功")
 }
@@ -0,0 +1,235 @@
+package handle
+
+import (
+	"encoding/json"
+	"fmt"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+登录防暴力破解:
+  - 按账号和 IP 分别统计登录失败次数 (用户不存在、密码错误、两步验证码错误), 统计窗口内每次失败重新计时
+  - IP 使用 c.ClientIP(), 只信任 Server.TrustedProxies 中的代理转发的请求头
+  - 账号或 IP 失败 CaptchaAfter 次后需要图片验证码; 账号每次失败后还需要等待 1, 2, 4... 秒 (最多 60 秒) 才能重试
+  - 账号失败 LockAfter 次或 IP 失败 IPLockAfter 次后锁定 LockTime 分钟, 锁定事件记录到操作日志;
+    锁定过期后失败次数仍在统计窗口内, 再次失败会立即重新锁定
+  - 用户不存在和密码错误返回同一个错误, 不泄露账号是否存在
+  - 登录成功只清除账号的失败次数; IP 的失败次数不清除, 防止攻击者登录自己的账号来重置计数
+*/
+
+const (
+	defaultCaptchaAfter = 3
+	defaultLockAfter    = 10
+	defaultIPLockAfter  = 50
+	defaultLockTime     = 15 * time.Minute
+	defaultFailWindow   = time.Hour
+	maxLoginDelay       = time.Minute // 锁定前每次失败后最长的等待时间
+)
+
+// loginPolicy 登录防暴力破解配置, 未配置的项使用默认值
+type loginPolicy struct {
+	CaptchaAfter int64
+	LockAfter    int64
+	IPLockAfter  int64
+	LockTime     time.Duration
+	FailWindow   time.Duration
+}
+
+func getLoginPolicy() loginPolicy {
+	conf := global.GetConfig().Login
+	p := loginPolicy{
+		CaptchaAfter: defaultCaptchaAfter,
+		LockAfter:    defaultLockAfter,
+		IPLockAfter:  defaultIPLockAfter,
+		LockTime:     defaultLockTime,
+		FailWindow:   defaultFailWindow,
+	}
+	if conf.CaptchaAfter > 0 {
+		p.CaptchaAfter = int64(conf.CaptchaAfter)
+	}
+	if conf.LockAfter > 0 {
+		p.LockAfter = int64(conf.LockAfter)
+	}
+	if conf.IPLockAfter > 0 {
+		p.IPLockAfter = int64(conf.IPLockAfter)
+	}
+	if conf.LockTime > 0 {
+		p.LockTime = time.Duration(conf.LockTime) * time.Minute
+	}
+	if conf.FailWindow > 0 {
+		p.FailWindow = time.Duration(conf.FailWindow) * time.Minute
+	}
+	return p
+}
+
+// delay 账号第 n 次失败后需要等待的时间: 达到验证码阈值后从 1 秒开始每次翻倍, 达到锁定阈值后为锁定时间
+func (p loginPolicy) delay(n int64) time.Duration {
+	if n >= p.LockAfter {
+		return p.LockTime
+	}
+	if n < p.CaptchaAfter {
+		return 0
+	}
+	return min(time.Second<<min(n-p.CaptchaAfter, 6), maxLoginDelay)
+}
+
+// dummyPasswordHash 用户不存在时也校验一次密码, 使响应时间与密码错误时一致
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := utils.BcryptHash(utils.RandomToken(16))
+	return hash
+})
+
+// loginGuard 一次登录请求的失败计数与锁定检查
+type loginGuard struct {
+	rdb      *redis.Client
+	policy   loginPolicy
+	username string
+	userAuth *model.UserAuth // 用户不存在时为 nil
+	account  string          // 账号的统计标识: user:用户id, 用户不存在时为 name:用户名
+	ip       string
+}
+
+func newLoginGuard(c *gin.Context, username string, userAuth *model.UserAuth) *loginGuard {
+	g := &loginGuard{
+		rdb:      GetRDB(c),
+		policy:   getLoginPolicy(),
+		username: username,
+		userAuth: userAuth,
+		ip:       c.ClientIP(),
+	}
+	if userAuth != nil {
+		g.account = loginAccountKey(userAuth.ID)
+	} else {
+		g.account = "name:" + strings.ToLower(strings.TrimSpace(username))
+	}
+	return g
+}
+
+func loginAccountKey(userId int) string {
+	return "user:" + strconv.Itoa(userId)
+}
+
+// checkLocked 账号或 IP 处于冷却/锁定中时直接返回错误响应, 响应头 Retry-After 为剩余秒数
+func (g *loginGuard) checkLocked(c *gin.Context) bool {
+	pipe := g.rdb.Pipeline()
+	account := pipe.PTTL(rctx, global.LOGIN_LOCK+g.account)
+	ip := pipe.PTTL(rctx, global.LOGIN_LOCK+"ip:"+g.ip)
+	if _, err := pipe.Exec(rctx); err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return false
+	}
+	// 不存在的 key 返回负数
+	wait := max(account.Val(), ip.Val())
+	if wait <= 0 {
+		return true
+	}
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	ReturnError(c, global.ErrLoginLocked, fmt.Sprintf("请 %d 秒后重试", seconds))
+	return false
+}
+
+// checkCaptcha 失败次数达到阈值时校验图片验证码, 验证码只能使用一次
+func (g *loginGuard) checkCaptcha(c *gin.Context, captchaId, captcha string) bool {
+	counts, err := g.rdb.MGet(rctx, global.LOGIN_FAIL+g.account, global.LOGIN_FAIL+"ip:"+g.ip).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return false
+	}
+	required := false
+	for _, v := range counts {
+		if s, ok := v.(string); ok {
+			n, _ := strconv.ParseInt(s, 10, 64)
+			required = required || n >= g.policy.CaptchaAfter
+		}
+	}
+	if !required {
+		return true
+	}
+
+	if captchaId == "" || captcha == "" {
+		ReturnError(c, global.ErrLoginCaptcha, nil)
+		return false
+	}
+	answer, err := g.rdb.GetDel(rctx, global.CAPTCHA+captchaId).Result()
+	if err != nil || answer != strings.TrimSpace(captcha) {
+		ReturnError(c, global.ErrCaptcha, nil)
+		return false
+	}
+	return true
+}
+
+// fail 记录一次登录失败, 按失败次数设置冷却或锁定; 出错只记录日志, 不影响返回的错误
+func (g *loginGuard) fail(c *gin.Context) {
+	accountKey, ipKey := global.LOGIN_FAIL+g.account, global.LOGIN_FAIL+"ip:"+g.ip
+	pipe := g.rdb.TxPipeline()
+	accountCount := pipe.Incr(rctx, accountKey)
+	pipe.Expire(rctx, accountKey, g.policy.FailWindow)
+	ipCount := pipe.Incr(rctx, ipKey)
+	pipe.Expire(rctx, ipKey, g.policy.FailWindow)
+	if _, err := pipe.Exec(rctx); err != nil {
+		slog.Error("记录登录失败次数失败", "account", g.account, "ip", g.ip, "err", err)
+		return
+	}
+
+	n := accountCount.Val()
+	if wait := g.policy.delay(n); wait > 0 {
+		if err := g.rdb.Set(rctx, global.LOGIN_LOCK+g.account, 1, wait).Err(); err != nil {
+			slog.Error("设置登录冷却失败", "account", g.account, "err", err)
+		}
+	}
+	if n >= g.policy.LockAfter {
+		g.logLock(c, fmt.Sprintf("账号 %s 连续登录失败 %d 次, 锁定 %d 分钟", g.username, n, int(g.policy.LockTime.Minutes())))
+	}
+
+	if m := ipCount.Val(); m >= g.policy.IPLockAfter {
+		if err := g.rdb.Set(rctx, global.LOGIN_LOCK+"ip:"+g.ip, 1, g.policy.LockTime).Err(); err != nil {
+			slog.Error("设置登录锁定失败", "ip", g.ip, "err", err)
+		}
+		g.logLock(c, fmt.Sprintf("IP %s 登录失败 %d 次, 锁定 %d 分钟", g.ip, m, int(g.policy.LockTime.Minutes())))
+	}
+}
+
+// logLock 登录接口不经过操作日志中间件, 锁定事件直接写入操作日志 (不记录密码)
+func (g *loginGuard) logLock(c *gin.Context, desc string) {
+	slog.Warn("登录锁定: " + desc)
+	param, _ := json.Marshal(map[string]string{"username": g.username})
+	operationLog := model.OperationLog{
+		OptModule:     "登录",
+		OptType:       "锁定",
+		OptUrl:        c.Request.RequestURI,
+		OptMethod:     c.HandlerName(),
+		OptDesc:       desc,
+		RequestParam:  string(param),
+		RequestMethod: c.Request.Method,
+		IpAddress:     g.ip,
+		IpSource:      utils.IP.GetIpSource(g.ip),
+	}
+	if g.userAuth != nil {
+		operationLog.UserId = g.userAuth.UserInfoId
+		if info, err := model.GetUserInfoById(GetDB(c), g.userAuth.UserInfoId); err == nil {
+			operationLog.Nickname = info.Nickname
+		}
+	}
+	if err := model.CreateOperationLog(GetDB(c), &operationLog); err != nil {
+		slog.Error("记录登录锁定日志失败", "err", err)
+	}
+}
+
+// clearLoginFailures 登录成功后清除账号的失败次数和冷却
+func clearLoginFailures(rdb *redis.Client, userId int) {
+	account := loginAccountKey(userId)
+	if err := rdb.Del(rctx, global.LOGIN_FAIL+account, global.LOGIN_LOCK+account).Err(); err != nil {
+		slog.Error("清除登录失败次数失败", "user", userId, "err", err)
+	}
+}
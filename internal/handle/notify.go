@@ -0,0 +1,405 @@
+package handle
+
+import (
+	"fmt"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"html"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// 评论通知去重标记的保留时间
+const commentNotifiedExpire = 7 * 24 * time.Hour
+
+// 站内通知只保存内容摘要 (完整内容通过跳转页面查看), 标题与 Notification.Title 的长度一致
+const (
+	notificationExcerptLen = 200
+	notificationTitleLen   = 100
+)
+
+// notifyComment 评论对外可见 (审核通过) 后发送通知, 邮件通过队列异步发送
+//   - 回复评论: 邮件通知父评论的作者 (父评论已删除时不通知)
+//   - 评论文章: 邮件通知文章作者
+//   - @提及: 站内通知 + 邮件通知被提及的用户
+//
+// 同一个用户只通知一次, 同一条评论只通知一次, 发送失败只记录日志, 不影响评论本身
+func notifyComment(db *gorm.DB, rdb *redis.Client, comment *model.Comment) {
+	if !comment.IsReview || comment.IsDelete {
+		return
+	}
+	first, err := rdb.SetNX(rctx, global.COMMENT_NOTIFIED+strconv.Itoa(comment.ID), true, commentNotifiedExpire).Result()
+	if err != nil || !first {
+		return
+	}
+
+	// 评论者昵称: 游客使用填写的昵称
+	nickname := comment.Nickname
+	if comment.UserId != 0 {
+		if user, err := model.GetUserAuthInfoById(db, comment.UserId); err == nil && user.UserInfo != nil {
+			nickname = user.UserInfo.Nickname
+		}
+	}
+
+	// 评论所在页面
+	var article *model.Article
+	title, path := "友链", "/link"
+	switch comment.Type {
+	case 1:
+		article, err = model.GetArticle(db, comment.TopicId)
+		if err != nil {
+			slog.Warn("评论通知: 获取文章失败", "article", comment.TopicId, "err", err)
+			return
+		}
+		title, path = article.Title, fmt.Sprintf("/article/%d", article.ID)
+	case 3:
+		title, path = "说说", fmt.Sprintf("/talk/%d", comment.TopicId)
+	}
+
+	data := map[string]string{
+		"Nickname": nickname,
+		"Content":  comment.Content, // Markdown 原文, 模版渲染时会转义
+		"Title":    title,
+		"URL":      utils.GetPublicURL(path),
+	}
+
+	// 评论者自己不需要通知
+	notified := map[int]bool{0: true, comment.UserId: true}
+	if comment.ParentId != 0 {
+		parent, err := model.GetCommentById(db, comment.ParentId)
+		if err != nil {
+			slog.Warn("评论通知: 获取父评论失败", "comment", comment.ParentId, "err", err)
+		} else if !parent.IsDelete && !notified[parent.UserId] {
+			notified[parent.UserId] = true
+			enqueueNotifyEmail(db, rdb, parent.UserId, utils.NOTIFY_REPLY, data)
+		}
+	}
+	if article != nil && !notified[article.UserId] {
+		notified[article.UserId] = true
+		enqueueNotifyEmail(db, rdb, article.UserId, utils.NOTIFY_COMMENT, data)
+	}
+	notifyMentions(db, rdb, model.MENTION_COMMENT, comment.ID, comment.UserId, data, notified)
+}
+
+// notifyMessage 留言对外可见 (审核通过) 后推送实时弹幕, 并通知留言中 @ 的用户, 同一条留言只通知一次
+func notifyMessage(db *gorm.DB, rdb *redis.Client, message *model.Message) {
+	if !message.IsReview {
+		return
+	}
+	first, err := rdb.SetNX(rctx, global.MESSAGE_NOTIFIED+strconv.Itoa(message.ID), true, commentNotifiedExpire).Result()
+	if err != nil || !first {
+		return
+	}
+	publishDanmaku(rdb, message)
+
+	data := map[string]string{
+		"Nickname": message.Nickname,
+		"Content":  html.UnescapeString(message.Content),
+		"Title":    "留言板",
+		"URL":      utils.GetPublicURL("/message"),
+	}
+	notifyMentions(db, rdb, model.MENTION_MESSAGE, message.ID, 0, data, map[int]bool{0: true})
+}
+
+// notifyMentions 通知评论/留言中 @ 的用户: 站内通知, 以及根据用户设置发送邮件
+// notified 中的用户已经收到过本条内容的通知, 不再重复通知
+func notifyMentions(db *gorm.DB, rdb *redis.Client, ownerType string, ownerId, senderId int, data map[string]string, notified map[int]bool) {
+	mentions, err := model.GetMentions(db, ownerType, ownerId)
+	if err != nil {
+		slog.Warn("获取 @ 的用户失败", "type", ownerType, "id", ownerId, "err", err)
+		return
+	}
+
+	for _, mention := range mentions {
+		if notified[mention.UserId] {
+			continue
+		}
+		notified[mention.UserId] = true
+
+		err := model.CreateNotification(db, &model.Notification{
+			UserId:   mention.UserId,
+			Type:     model.NOTIFICATION_MENTION,
+			SenderId: senderId,
+			Title:    truncate(fmt.Sprintf("%s 在「%s」中提到了你", data["Nickname"], data["Title"]), notificationTitleLen),
+			Content:  excerpt(data["Content"], notificationExcerptLen),
+			Url:      data["URL"],
+		})
+		if err != nil {
+			slog.Warn("创建站内通知失败", "user", mention.UserId, "err", err)
+		}
+		enqueueNotifyEmail(db, rdb, mention.UserId, utils.NOTIFY_MENTION, data)
+	}
+}
+
+// excerpt 按字符截取摘要, 截断时末尾加省略号
+func excerpt(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return truncate(s, n-1) + "…"
+}
+
+// 单条评论/留言最多 @ 的用户数量, 未配置时的默认值
+const defaultMaxMentions = 5
+
+// resolveMentions 解析内容中 @ 的用户, 超过数量限制时直接返回错误响应
+// 数量限制按 @ 的昵称计算 (无论用户是否存在), 防止通过大量 @ 刷屏
+func resolveMentions(c *gin.Context, content string) ([]model.UserAuth, bool) {
+	names := model.ParseMentions(content)
+	if len(names) == 0 {
+		return nil, true
+	}
+
+	db := GetDB(c)
+	if limit := model.GetConfigInt(db, global.CONFIG_MAX_MENTIONS, defaultMaxMentions); len(names) > limit {
+		ReturnError(c, global.ErrMentionLimit, nil)
+		return nil, false
+	}
+	users, err := model.GetUsersByNicknames(db, names)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return nil, false
+	}
+	return users, true
+}
+
+// notifyModeration 将审核结果通知作者: 注册用户发送站内通知, 游客发送邮件 (游客没有站内通知)
+func notifyModeration(db *gorm.DB, rdb *redis.Client, typ string, target model.ModerationTarget, action, reason string) {
+	label := map[string]string{
+		model.MODERATION_COMMENT: "评论",
+		model.MODERATION_MESSAGE: "留言",
+		model.MODERATION_LINK:    "友链申请",
+	}[typ]
+	result := map[string]string{
+		model.MODERATION_APPROVE: "已通过审核",
+		model.MODERATION_REJECT:  "未通过审核",
+		model.MODERATION_DELETE:  "已被删除",
+		model.MODERATION_SPAM:    "已被删除",
+	}[action]
+	title := fmt.Sprintf("你的%s%s", label, result)
+
+	if target.UserId != 0 {
+		err := model.CreateNotification(db, &model.Notification{
+			UserId:  target.UserId,
+			Type:    model.NOTIFICATION_MODERATION,
+			Title:   title,
+			Content: reason,
+		})
+		if err != nil {
+			slog.Warn("创建站内通知失败", "user", target.UserId, "err", err)
+		}
+		return
+	}
+	if target.Email == "" {
+		return
+	}
+
+	err := utils.EnqueueEmail(rctx, rdb, utils.EmailTask{
+		To:       target.Email,
+		Subject:  title,
+		Template: "moderation.tpl",
+		Data: map[string]string{
+			"Subject":  title,
+			"UserName": target.Nickname,
+			"Title":    title,
+			"Reason":   reason,
+			"Content":  target.Content,
+		},
+	})
+	if err != nil {
+		slog.Error("审核通知邮件入队失败", "email", target.Email, "err", err)
+	}
+}
+
+// enqueueNotifyEmail 根据用户的通知设置, 将通知邮件加入发送队列
+func enqueueNotifyEmail(db *gorm.DB, rdb *redis.Client, userId int, kind string, data map[string]string) {
+	user, err := model.GetUserAuthInfoById(db, userId)
+	if err != nil || user.UserInfo == nil || user.UserInfo.Email == "" {
+		return
+	}
+	info := user.UserInfo
+
+	var subject, tpl string
+	switch kind {
+	case utils.NOTIFY_REPLY:
+		if !info.NotifyReply {
+			return
+		}
+		subject, tpl = "你的评论有新回复", "comment-reply.tpl"
+	case utils.NOTIFY_COMMENT:
+		if !info.NotifyComment {
+			return
+		}
+		subject, tpl = "你的文章有新评论", "comment-new.tpl"
+	case utils.NOTIFY_MENTION:
+		if !info.NotifyMention {
+			return
+		}
+		subject, tpl = "有人提到了你", "mention.tpl"
+	default:
+		return
+	}
+
+	unsubscribe := utils.GetUnsubscribeURL(userId, kind)
+	data = maps.Clone(data)
+	data["Subject"] = subject
+	data["UserName"] = info.Nickname
+	data["UnsubscribeURL"] = unsubscribe
+
+	err = utils.EnqueueEmail(rctx, rdb, utils.EmailTask{
+		To:       info.Email,
+		Subject:  subject,
+		Template: tpl,
+		Data:     data,
+		// 支持邮件客户端的一键退订 (RFC 8058)
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+	if err != nil {
+		slog.Error("通知邮件入队失败", "user", userId, "kind", kind, "err", err)
+	}
+}
+
+type UpdateNotifyReq struct {
+	NotifyReply   bool `json:"notify_reply"`
+	NotifyComment bool `json:"notify_comment"`
+	NotifyMention bool `json:"notify_mention"`
+}
+
+// UpdateNotify 修改当前用户的邮件通知设置
+func (*User) UpdateNotify(c *gin.Context) {
+	var req UpdateNotifyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	if err := model.UpdateUserNotify(GetDB(c), auth.UserInfoId, req.NotifyReply, req.NotifyComment, req.NotifyMention); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, nil)
+}
+
+// 各类邮件通知的名称, 用于退订页面
+var notifyKindLabels = map[string]string{
+	utils.NOTIFY_REPLY:   "评论被回复",
+	utils.NOTIFY_COMMENT: "文章新评论",
+	utils.NOTIFY_MENTION: "被 @ 提及",
+}
+
+// unsubscribeParams 校验退订链接的参数和签名
+func unsubscribeParams(c *gin.Context) (userId int, kind string, ok bool) {
+	userId, err := strconv.Atoi(c.Query("user_id"))
+	kind = c.Query("type")
+	if _, known := notifyKindLabels[kind]; err != nil || !known ||
+		!utils.VerifyUnsubscribe(global.GetConfig().JWT.Secret, userId, kind, c.Query("sign")) {
+		return 0, "", false
+	}
+	return userId, kind, true
+}
+
+// UnsubscribePage 用户点击邮件中的退订链接, 只展示确认页面, 不修改订阅
+// 邮件安全网关、链接预览等会自动访问邮件中的链接, GET 请求不能产生副作用
+func (*User) UnsubscribePage(c *gin.Context) {
+	userId, kind, ok := unsubscribeParams(c)
+	if !ok {
+		renderUnsubscribePage(c, http.StatusBadRequest, map[string]any{"Title": "退订失败", "Message": "退订链接无效"})
+		return
+	}
+	renderUnsubscribePage(c, http.StatusOK, map[string]any{
+		"Title":   "退订邮件通知",
+		"Message": fmt.Sprintf("确认后你将不再收到「%s」的邮件通知", notifyKindLabels[kind]),
+		"Confirm": true,
+		"Action":  utils.GetUnsubscribeURL(userId, kind),
+	})
+}
+
+// Unsubscribe 退订邮件通知, 通过签名校验, 不需要登录
+// 来自确认页面的提交, 或邮件客户端的一键退订 (List-Unsubscribe-Post, RFC 8058)
+func (*User) Unsubscribe(c *gin.Context) {
+	userId, kind, ok := unsubscribeParams(c)
+	if !ok {
+		renderUnsubscribePage(c, http.StatusBadRequest, map[string]any{"Title": "退订失败", "Message": "退订链接无效"})
+		return
+	}
+
+	if err := model.UnsubscribeUserNotify(GetDB(c), userId, kind); err != nil {
+		slog.Error("退订邮件通知失败", "user", userId, "kind", kind, "err", err)
+		renderUnsubscribePage(c, http.StatusInternalServerError, map[string]any{"Title": "退订失败", "Message": "请稍后再试"})
+		return
+	}
+	renderUnsubscribePage(c, http.StatusOK, map[string]any{
+		"Title":   "退订成功",
+		"Success": true,
+		"Message": fmt.Sprintf("你将不再收到「%s」的邮件通知", notifyKindLabels[kind]),
+	})
+}
+
+// renderUnsubscribePage 渲染退订的确认和结果页面
+func renderUnsubscribePage(c *gin.Context, status int, data map[string]any) {
+	data["HomeURL"] = utils.GetPublicURL("/")
+	renderPage(c, status, "unsubscribe.tpl", data, fmt.Sprint(data["Title"], ": ", data["Message"]))
+}
+
+type NotificationQuery struct {
+	PageQuery
+}
+
+type NotificationVO struct {
+	PageResult[model.Notification]
+	Unread int `json:"unread"` // 未读数量
+}
+
+// GetNotificationList 当前用户的站内通知列表
+func (*User) GetNotificationList(c *gin.Context) {
+	var query NotificationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	list, total, unread, err := model.GetNotificationList(GetDB(c), auth.ID, query.Page, query.Size)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, NotificationVO{
+		PageResult: PageResult[model.Notification]{
+			Total: int(total),
+			List:  list,
+			Size:  query.Size,
+			Page:  query.Page,
+		},
+		Unread: int(unread),
+	})
+}
+
+// ReadNotifications 将站内通知标记为已读, ids 为空时全部标记为已读
+func (*User) ReadNotifications(c *gin.Context) {
+	var ids []int
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	rows, err := model.ReadNotifications(GetDB(c), auth.ID, ids)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, rows)
+}
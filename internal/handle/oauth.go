@@ -0,0 +1,347 @@
+package handle
+
+import (
+	"encoding/json"
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"gin-blog-server/internal/utils/oauth"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+第三方登录流程:
+ 1. 前端跳转到 /api/oauth/:provider/login?redirect=/path, 服务端保存 state 和 PKCE 参数, 并将 state 写入 cookie, 再跳转到提供方授权页面
+ 2. 提供方回调 /api/oauth/:provider/callback, 校验 state (与 cookie 一致且只能使用一次) 后换取第三方账号信息, 找到或创建对应的用户
+ 3. 服务端跳转回前端 {PublicURL}{redirect}#oauth_code=xxx, 失败时为 #oauth_error=原因
+ 4. 前端使用一次性的 oauth_code 调用 /api/oauth/login 完成登录, 与密码登录一样返回令牌 (开启两步验证时返回预认证令牌)
+
+登录码通过 URL 片段传递, 不会出现在服务端日志和 Referer 中, 且有效期很短、只能使用一次
+*/
+
+const (
+	oauthStateExpire = 10 * time.Minute // 授权请求有效期
+	oauthLoginExpire = time.Minute      // 一次性登录码有效期
+)
+
+// oauthState 授权请求, 回调时使用
+type oauthState struct {
+	oauth.AuthRequest
+	Provider string `json:"provider"`
+	Redirect string `json:"redirect"` // 登录后跳转的前端地址
+}
+
+// oauthProviders 提供方实例缓存, OIDC 提供方会缓存元数据和公钥
+var oauthProviders sync.Map
+
+// getOAuthProvider 根据名称获取已配置的提供方
+func getOAuthProvider(name string) (oauth.Provider, error) {
+	name = strings.ToLower(name)
+	if p, ok := oauthProviders.Load(name); ok {
+		return p.(oauth.Provider), nil
+	}
+	conf, ok := global.GetConfig().OAuth.Providers[name]
+	if !ok {
+		return nil, errors.New("oauth provider not configured: " + name)
+	}
+	redirectURL := conf.RedirectURL
+	if redirectURL == "" {
+		redirectURL = utils.GetPublicURL("/api/oauth/" + name + "/callback")
+	}
+	p, err := oauth.NewProvider(name, conf, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := oauthProviders.LoadOrStore(name, p)
+	return actual.(oauth.Provider), nil
+}
+
+// safeRedirect 只允许跳转到本站的相对路径, 防止开放重定向
+func safeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.ContainsAny(redirect, "\\#\r\n") {
+		return "/"
+	}
+	return redirect
+}
+
+// GetOAuthProviders 获取已配置的第三方登录方式, 前端据此显示登录按钮
+//
+//	@Summary		第三方登录方式
+//	@Description	获取已配置的第三方登录提供方名称
+//	@Tags			UserAuth
+//	@Produce		json
+//	@Success		0	{object}	Response[[]string]
+//	@Router			/oauth/providers [get]
+func (*UserAuth) GetOAuthProviders(c *gin.Context) {
+	names := make([]string, 0)
+	for name, conf := range global.GetConfig().OAuth.Providers {
+		if conf.ClientID != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	ReturnSuccess(c, names)
+}
+
+// OAuthLogin 跳转到第三方登录的授权页面
+//
+//	@Summary		第三方登录
+//	@Description	跳转到提供方授权页面, 授权后回调 /oauth/{provider}/callback
+//	@Tags			UserAuth
+//	@Param			provider	path	string	true	"提供方名称"
+//	@Param			redirect	query	string	false	"登录后跳转的前端路径"
+//	@Success		302
+//	@Router			/oauth/{provider}/login [get]
+func (*UserAuth) OAuthLogin(c *gin.Context) {
+	name := strings.ToLower(c.Param("provider"))
+	provider, err := getOAuthProvider(name)
+	if err != nil {
+		ReturnError(c, global.ErrOAuthProvider, err)
+		return
+	}
+
+	state := oauthState{
+		AuthRequest: oauth.NewAuthRequest(),
+		Provider:    name,
+		Redirect:    safeRedirect(c.Query("redirect")),
+	}
+	authURL, err := provider.AuthURL(c.Request.Context(), state.AuthRequest)
+	if err != nil {
+		slog.Error("生成第三方登录链接失败", "provider", name, "err", err)
+		ReturnError(c, global.ErrOAuthProvider, nil)
+		return
+	}
+
+	data, _ := json.Marshal(state)
+	if err := GetRDB(c).Set(rctx, global.OAUTH_STATE+state.State, data, oauthStateExpire).Err(); err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	// state 同时写入 cookie, 回调时校验是同一个浏览器发起的授权, 防止登录 CSRF
+	// 提供方回调是跨站的顶级导航, SameSite=Lax 的 cookie 仍会携带
+	secure := strings.HasPrefix(global.GetConfig().Server.PublicURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(global.COOKIE_OAUTH_STATE, state.State, int(oauthStateExpire.Seconds()), "/api/oauth", "", secure, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OAuthCallback 第三方登录回调, 完成后跳转回前端
+//
+//	@Summary		第三方登录回调
+//	@Description	校验 state 并换取第三方账号信息, 跳转回前端并在 URL 片段中带上一次性登录码 oauth_code (失败时为 oauth_error)
+//	@Tags			UserAuth
+//	@Param			provider	path	string	true	"提供方名称"
+//	@Param			code		query	string	true	"授权码"
+//	@Param			state		query	string	true	"state"
+//	@Success		302
+//	@Router			/oauth/{provider}/callback [get]
+func (*UserAuth) OAuthCallback(c *gin.Context) {
+	name := strings.ToLower(c.Param("provider"))
+	db, rdb := GetDB(c), GetRDB(c)
+
+	// state 只能使用一次, 且必须与发起授权的浏览器 cookie 一致
+	stateId := c.Query("state")
+	cookie, _ := c.Cookie(global.COOKIE_OAUTH_STATE)
+	c.SetCookie(global.COOKIE_OAUTH_STATE, "", -1, "/api/oauth", "", false, true)
+	if stateId == "" || cookie != stateId {
+		oauthFail(c, "/", "登录请求无效, 请重新登录")
+		return
+	}
+	data, err := rdb.GetDel(rctx, global.OAUTH_STATE+stateId).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Error("获取第三方登录请求失败", "err", err)
+		}
+		oauthFail(c, "/", "登录请求已过期, 请重新登录")
+		return
+	}
+	var state oauthState
+	if err := json.Unmarshal(data, &state); err != nil || state.Provider != name {
+		oauthFail(c, "/", "登录请求无效, 请重新登录")
+		return
+	}
+	if c.Query("error") != "" || c.Query("code") == "" {
+		oauthFail(c, state.Redirect, "已取消授权")
+		return
+	}
+
+	provider, err := getOAuthProvider(name)
+	if err != nil {
+		oauthFail(c, state.Redirect, "不支持该第三方登录方式")
+		return
+	}
+	identity, err := provider.Identify(c.Request.Context(), c.Query("code"), state.AuthRequest)
+	if err != nil {
+		slog.Warn("第三方登录获取用户信息失败", "provider", name, "err", err)
+		oauthFail(c, state.Redirect, "获取第三方账号信息失败, 请重试")
+		return
+	}
+
+	userAuth, err := oauthUser(db, name, identity)
+	if err != nil {
+		slog.Error("第三方登录关联用户失败", "provider", name, "err", err)
+		oauthFail(c, state.Redirect, "登录失败, 请重试")
+		return
+	}
+	if userAuth.IsDisable {
+		oauthFail(c, state.Redirect, "该账号已被禁用")
+		return
+	}
+
+	code := utils.RandomToken(32)
+	if err := rdb.Set(rctx, global.OAUTH_LOGIN+utils.SHA256(code), userAuth.ID, oauthLoginExpire).Err(); err != nil {
+		slog.Error("保存第三方登录码失败", "err", err)
+		oauthFail(c, state.Redirect, "登录失败, 请重试")
+		return
+	}
+	c.Redirect(http.StatusFound, utils.GetPublicURL(state.Redirect)+"#oauth_code="+code)
+}
+
+// oauthFail 第三方登录失败, 跳转回前端并在 URL 片段中带上原因
+func oauthFail(c *gin.Context, redirect, msg string) {
+	c.Redirect(http.StatusFound, utils.GetPublicURL(redirect)+"#oauth_error="+url.QueryEscape(msg))
+}
+
+// oauthUser 获取第三方账号对应的用户:
+// 已关联时直接返回; 否则提供方确认已验证的邮箱与已有用户一致时关联该用户; 都没有时自动创建用户
+func oauthUser(db *gorm.DB, provider string, identity *oauth.Identity) (*model.UserAuth, error) {
+	account, err := model.GetOAuthAccount(db, provider, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		return model.GetUserAuthInfoById(db, account.UserAuthId)
+	}
+
+	account = &model.OAuthAccount{
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		Nickname: truncate(identity.Nickname, 100),
+	}
+
+	// 只有已验证的邮箱才能关联已有用户, 否则任何人都可以在提供方填写他人邮箱来登录他人账号
+	if identity.EmailVerified && identity.Email != "" {
+		userAuth, err := model.GetUserAuthByEmail(db, identity.Email)
+		if err == nil {
+			account.UserAuthId = userAuth.ID
+			if err := model.CreateOAuthAccount(db, account); err != nil {
+				return nil, err
+			}
+			slog.Info("第三方账号关联已有用户", "provider", provider, "user", userAuth.ID)
+			return model.GetUserAuthInfoById(db, userAuth.ID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	// 首次登录自动创建用户, 使用第三方账号的昵称和头像
+	nickname, err := oauthNickname(db, provider, identity.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	info := &model.UserInfo{
+		Nickname: nickname,
+		Avatar:   identity.Avatar,
+	}
+	if info.Avatar == "" {
+		info.Avatar = utils.Gravatar(identity.Email)
+	}
+	username := provider + "_" + identity.Subject
+	if len(username) > 50 {
+		username = provider + "_" + utils.SHA256(identity.Subject)[:32]
+	}
+	if identity.EmailVerified && identity.Email != "" && len(identity.Email) <= 30 {
+		info.Email, username = identity.Email, identity.Email
+	}
+
+	userAuth, err := model.CreateOAuthUser(db, username, info, account)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("第三方登录创建用户", "provider", provider, "user", userAuth.ID)
+	return model.GetUserAuthInfoById(db, userAuth.ID)
+}
+
+// oauthNickname 第三方账号的昵称, 已被使用时加随机后缀 (昵称唯一)
+func oauthNickname(db *gorm.DB, provider, nickname string) (string, error) {
+	nickname = truncate(strings.TrimSpace(nickname), 20)
+	if nickname == "" {
+		nickname = provider + "用户"
+	}
+	candidate := nickname
+	for i := 0; i < 5; i++ {
+		exist, err := model.ExistUserNickname(db, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exist {
+			return candidate, nil
+		}
+		candidate = nickname + "_" + utils.RandomToken(3)
+	}
+	return candidate, nil
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+type OAuthLoginReq struct {
+	Code string `json:"code" binding:"required"` // 回调跳转时 URL 片段中的 oauth_code
+}
+
+// OAuthExchange 使用第三方登录回调得到的一次性登录码完成登录
+//
+//	@Summary		第三方登录 (换取令牌)
+//	@Description	使用回调跳转时的 oauth_code 完成登录, 开启两步验证时返回预认证令牌
+//	@Tags			UserAuth
+//	@Param			form	body	OAuthLoginReq	true	"一次性登录码"
+//	@Accept			json
+//	@Produce		json
+//	@Success		0	{object}	Response[LoginVO]
+//	@Router			/oauth/login [post]
+func (*UserAuth) OAuthExchange(c *gin.Context) {
+	var req OAuthLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	uid, err := GetRDB(c).GetDel(rctx, global.OAUTH_LOGIN+utils.SHA256(req.Code)).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			ReturnError(c, global.ErrOAuthCode, nil)
+			return
+		}
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+
+	userAuth, err := model.GetUserAuthInfoById(GetDB(c), uid)
+	if err != nil {
+		ReturnError(c, global.ErrUserNotExist, err)
+		return
+	}
+	if userAuth.IsDisable {
+		ReturnError(c, global.ErrUserDisabled, nil)
+		return
+	}
+	loginWithSecondFactor(c, userAuth)
+}
@@ -0,0 +1,136 @@
+package handle
+
+import (
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"strconv"
+)
+
+// 用户主页展示的最新文章数和最近评论数, 更多文章通过文章列表的 user_id 参数分页查询
+const (
+	profileArticleCount = 10
+	profileCommentCount = 10
+)
+
+// UserProfileVO 前台用户主页, 用户不公开主页时只有资料中的昵称、头像和注册时间
+type UserProfileVO struct {
+	model.UserProfile
+	Stats    *model.UserStats           `json:"stats,omitempty"`
+	Articles []model.RecommendArticleVO `json:"articles"`
+	Comments []model.UserCommentVO      `json:"comments"`
+}
+
+// GetUserProfile 用户主页: 公开的资料、发表的文章、最近的评论和统计数据
+func (*Front) GetUserProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	profile, err := model.GetUserProfile(db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ReturnError(c, global.ErrUserNotExist, nil)
+			return
+		}
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	data := UserProfileVO{UserProfile: *profile}
+	if !profile.IsPublic {
+		data.Intro, data.Website = "", ""
+		ReturnSuccess(c, data)
+		return
+	}
+
+	articleIds, err := model.GetUserArticleIds(db, id)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	data.Stats, err = model.GetUserStats(db, id)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	data.Stats.ArticleCount = int64(len(articleIds))
+	data.Stats.LikeCount += articleLikeCount(GetRDB(c), articleIds)
+
+	data.Articles, err = model.GetUserNewestArticles(db, id, profileArticleCount)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	data.Comments, err = model.GetUserRecentComments(db, id, profileCommentCount)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, data)
+}
+
+// articleLikeCount 文章获得的点赞总数, Redis 读取失败时只记录日志, 不影响页面展示
+func articleLikeCount(rdb *redis.Client, ids []int) int64 {
+	if len(ids) == 0 {
+		return 0
+	}
+	fields := make([]string, 0, len(ids))
+	for _, id := range ids {
+		fields = append(fields, strconv.Itoa(id))
+	}
+	vals, err := rdb.HMGet(rctx, global.ARTICLE_LIKE_COUNT, fields...).Result()
+	if err != nil {
+		slog.Error("获取文章点赞数失败", "err", err)
+		return 0
+	}
+	var total int64
+	for _, v := range vals {
+		if s, ok := v.(string); ok {
+			n, _ := strconv.ParseInt(s, 10, 64)
+			total += n
+		}
+	}
+	return total
+}
+
+// GetAuthorList 作者列表: 发表过公开文章的用户, 不公开主页的用户不展示简介和网站
+func (*Front) GetAuthorList(c *gin.Context) {
+	list, err := model.GetAuthorList(GetDB(c))
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	for i := range list {
+		if !list[i].IsPublic {
+			list[i].Intro, list[i].Website = "", ""
+		}
+	}
+	ReturnSuccess(c, list)
+}
+
+type UpdatePrivacyReq struct {
+	IsPublic bool `json:"is_public"`
+}
+
+// UpdatePrivacy 修改当前用户是否公开个人主页
+func (*User) UpdatePrivacy(c *gin.Context) {
+	var req UpdatePrivacyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	if err := model.UpdateUserPrivacy(GetDB(c), auth.UserInfoId, req.IsPublic); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, nil)
+}
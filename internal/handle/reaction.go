@@ -0,0 +1,259 @@
+package handle
+
+import (
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/*
+表情回应 (reaction):
+  - 可用的表情由配置项 reaction_emojis 决定 (逗号分隔), 前台通过 /config 获取
+  - Redis 中每个对象一个 Hash 保存各表情的数量, 每个用户每类对象一个 Set 保存回应过的 "对象id:表情"
+  - 切换回应通过 Lua 脚本原子执行 (判断 + 修改集合 + 修改计数), 并发请求不会导致计数不一致
+  - 每条回应同时持久化到数据库, Redis 数据丢失时从数据库重建 (见 LoadReactions)
+*/
+
+// 未配置 reaction_emojis 时默认可用的表情
+const defaultReactionEmojis = "👍,❤️,😄,🎉,😕,👀"
+
+// toggleReactionScript 切换表情回应
+// KEYS[1]: 用户回应 Set, KEYS[2]: 对象的计数 Hash
+// ARGV[1]: Set 成员 (对象id:表情), ARGV[2]: 表情
+// 返回 {是否已回应(1/0), 该表情切换后的数量}
+var toggleReactionScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[1], ARGV[1]) == 1 then
+	redis.call('SREM', KEYS[1], ARGV[1])
+	local count = redis.call('HINCRBY', KEYS[2], ARGV[2], -1)
+	if count <= 0 then
+		redis.call('HDEL', KEYS[2], ARGV[2])
+		count = 0
+	end
+	return {0, count}
+end
+redis.call('SADD', KEYS[1], ARGV[1])
+return {1, redis.call('HINCRBY', KEYS[2], ARGV[2], 1)}
+`)
+
+func reactionCountKey(targetType string, targetId int) string {
+	return global.REACTION_COUNT + targetType + ":" + strconv.Itoa(targetId)
+}
+
+func reactionUserKey(targetType string, userId int) string {
+	return global.REACTION_USER + targetType + ":" + strconv.Itoa(userId)
+}
+
+func reactionMember(targetId int, emoji string) string {
+	return strconv.Itoa(targetId) + ":" + emoji
+}
+
+// reactionEmojis 当前可用的表情
+func reactionEmojis(db *gorm.DB) []string {
+	val := model.GetConfig(db, global.CONFIG_REACTION_EMOJIS)
+	if val == "" {
+		val = defaultReactionEmojis
+	}
+	emojis := make([]string, 0)
+	for _, emoji := range strings.Split(val, ",") {
+		if emoji = strings.TrimSpace(emoji); emoji != "" {
+			emojis = append(emojis, emoji)
+		}
+	}
+	return emojis
+}
+
+type ReactionReq struct {
+	TargetType string `json:"target_type" binding:"required,oneof=article comment"`
+	TargetId   int    `json:"target_id" binding:"required"`
+	Emoji      string `json:"emoji" binding:"required"`
+}
+
+type ReactionVO struct {
+	Reacted bool `json:"reacted"` // 切换后当前用户是否已回应该表情
+	Count   int  `json:"count"`   // 切换后该表情的数量
+}
+
+// ToggleReaction 对文章/评论添加或取消表情回应
+func (*Front) ToggleReaction(c *gin.Context) {
+	var req ReactionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	if !slices.Contains(reactionEmojis(db), req.Emoji) {
+		ReturnError(c, global.ErrReactionEmoji, nil)
+		return
+	}
+
+	// 只能回应存在且对外可见的对象, 草稿、私密、回收站中的文章和未审核的评论按不存在处理
+	switch req.TargetType {
+	case model.REACTION_ARTICLE:
+		if _, err := model.GetBlogArticle(db, req.TargetId); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ReturnError(c, global.ErrArticleNotExist, nil)
+				return
+			}
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+	case model.REACTION_COMMENT:
+		comment, err := model.GetCommentById(db, req.TargetId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ReturnError(c, global.ErrCommentNotExist, nil)
+				return
+			}
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		if comment.IsDelete {
+			ReturnError(c, global.ErrCommentDeleted, nil)
+			return
+		}
+		if !comment.IsReview {
+			ReturnError(c, global.ErrCommentNotExist, nil)
+			return
+		}
+	}
+
+	auth, _ := CurrentUserAuth(c)
+	rdb := GetRDB(c)
+	result, err := toggleReaction(rdb, req.TargetType, req.TargetId, auth.ID, req.Emoji)
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+
+	if err := model.SaveReaction(db, req.TargetType, req.TargetId, auth.ID, req.Emoji, result.Reacted); err != nil {
+		// 数据库保存失败, 再切换一次恢复 Redis 中的数据
+		if _, err := toggleReaction(rdb, req.TargetType, req.TargetId, auth.ID, req.Emoji); err != nil {
+			slog.Error("恢复表情回应失败", "type", req.TargetType, "id", req.TargetId, "user", auth.ID, "err", err)
+		}
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, result)
+}
+
+func toggleReaction(rdb *redis.Client, targetType string, targetId, userId int, emoji string) (ReactionVO, error) {
+	keys := []string{reactionUserKey(targetType, userId), reactionCountKey(targetType, targetId)}
+	vals, err := toggleReactionScript.Run(rctx, rdb, keys, reactionMember(targetId, emoji), emoji).Int64Slice()
+	if err != nil {
+		return ReactionVO{}, err
+	}
+	if len(vals) != 2 {
+		return ReactionVO{}, errors.New("表情回应脚本返回值错误")
+	}
+	return ReactionVO{Reacted: vals[0] == 1, Count: int(vals[1])}, nil
+}
+
+// getReactions 批量获取对象的表情回应数量, 以及当前用户 (userId 不为 0 时) 回应过的表情
+// Redis 读取失败时只记录日志, 不影响页面展示
+func getReactions(rdb *redis.Client, targetType string, ids []int, userId int) (counts map[int]map[string]int, mine map[int][]string) {
+	counts = make(map[int]map[string]int, len(ids))
+	mine = make(map[int][]string)
+	if len(ids) == 0 {
+		return counts, mine
+	}
+
+	pipe := rdb.Pipeline()
+	countCmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		countCmds[i] = pipe.HGetAll(rctx, reactionCountKey(targetType, id))
+	}
+	var userCmd *redis.StringSliceCmd
+	if userId != 0 {
+		userCmd = pipe.SMembers(rctx, reactionUserKey(targetType, userId))
+	}
+	if _, err := pipe.Exec(rctx); err != nil && !errors.Is(err, redis.Nil) {
+		slog.Warn("获取表情回应失败", "type", targetType, "err", err)
+		return counts, mine
+	}
+
+	for i, id := range ids {
+		counts[id] = make(map[string]int)
+		for emoji, val := range countCmds[i].Val() {
+			if n, _ := strconv.Atoi(val); n > 0 {
+				counts[id][emoji] = n
+			}
+		}
+	}
+	if userCmd != nil {
+		for _, member := range userCmd.Val() {
+			idStr, emoji, ok := strings.Cut(member, ":")
+			if id, err := strconv.Atoi(idStr); ok && err == nil {
+				if _, exist := counts[id]; exist {
+					mine[id] = append(mine[id], emoji)
+				}
+			}
+		}
+		for _, emojis := range mine {
+			slices.Sort(emojis)
+		}
+	}
+	return counts, mine
+}
+
+// fillCommentReactions 为评论树填充表情回应数量及当前用户的回应
+func fillCommentReactions(c *gin.Context, list []model.CommentVO) {
+	var ids []int
+	var collect func([]model.CommentVO)
+	collect = func(list []model.CommentVO) {
+		for _, v := range list {
+			ids = append(ids, v.ID)
+			collect(v.ReplyList)
+		}
+	}
+	collect(list)
+
+	counts, mine := getReactions(GetRDB(c), model.REACTION_COMMENT, ids, commentViewer(c).UserId)
+	var fill func([]model.CommentVO)
+	fill = func(list []model.CommentVO) {
+		for i := range list {
+			list[i].Reactions = counts[list[i].ID]
+			list[i].MyReactions = mine[list[i].ID]
+			fill(list[i].ReplyList)
+		}
+	}
+	fill(list)
+}
+
+// LoadReactions Redis 中没有表情回应数据时 (首次启动或 Redis 数据丢失), 从数据库重建
+func LoadReactions(db *gorm.DB, rdb *redis.Client) error {
+	loaded, err := rdb.SetNX(rctx, global.REACTION_LOADED, true, 0).Result()
+	if err != nil || !loaded {
+		return err
+	}
+
+	for _, targetType := range []string{model.REACTION_ARTICLE, model.REACTION_COMMENT} {
+		list, err := model.GetReactions(db, targetType)
+		if err != nil {
+			rdb.Del(rctx, global.REACTION_LOADED)
+			return err
+		}
+
+		// 先清除残留的数据, 防止重复计数
+		pipe := rdb.TxPipeline()
+		for _, r := range list {
+			pipe.Del(rctx, reactionUserKey(targetType, r.UserId), reactionCountKey(targetType, r.TargetId))
+		}
+		for _, r := range list {
+			pipe.SAdd(rctx, reactionUserKey(targetType, r.UserId), reactionMember(r.TargetId, r.Emoji))
+			pipe.HIncrBy(rctx, reactionCountKey(targetType, r.TargetId), r.Emoji, 1)
+		}
+		if _, err := pipe.Exec(rctx); err != nil {
+			rdb.Del(rctx, global.REACTION_LOADED)
+			return err
+		}
+	}
+	return nil
+}
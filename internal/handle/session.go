@@ -0,0 +1,151 @@
+package handle
+
+import (
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionSeenInterval 同一个访问令牌两次更新会话最近活跃时间的最短间隔, 避免每个请求都写数据库
+const sessionSeenInterval = time.Minute
+
+// recordSession 登录成功后记录登录会话: 设备、IP 和所属的令牌族
+func recordSession(c *gin.Context, db *gorm.DB, userId int, token *TokenVO) error {
+	ipAddress := utils.IP.GetIpAddress(c)
+	session := model.UserSession{
+		UserId:     userId,
+		FamilyId:   token.familyId,
+		IpAddress:  truncate(ipAddress, 50),
+		IpSource:   truncate(utils.IP.GetIpSourceSimpleIdle(ipAddress), 100),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  token.expiresAt,
+	}
+	if ua := utils.IP.GetUserAgent(c); ua != nil {
+		session.Browser = truncate(strings.TrimSpace(ua.Name+" "+ua.Version.String()), 50)
+		session.OS = truncate(strings.TrimSpace(ua.OS+" "+ua.OSVersion.String()), 50)
+	}
+	return model.CreateUserSession(db, &session)
+}
+
+// TouchSession 更新访问令牌所在会话的最近活跃时间, 每个访问令牌每分钟最多更新一次, 失败只记录日志
+func TouchSession(db *gorm.DB, rdb *redis.Client, jti string) {
+	if jti == "" {
+		return
+	}
+	first, err := rdb.SetNX(rctx, global.SESSION_SEEN+jti, true, sessionSeenInterval).Result()
+	if err != nil || !first {
+		return
+	}
+	if err := model.TouchUserSession(db, jti); err != nil {
+		slog.Error("更新登录会话活跃时间失败", "err", err)
+	}
+}
+
+// revokeUserSession 吊销一个登录会话: 令牌族中的刷新令牌和访问令牌都立即失效
+func revokeUserSession(db *gorm.DB, rdb *redis.Client, session *model.UserSession) error {
+	jtis, err := model.RevokeTokenFamily(db, session.FamilyId)
+	if err != nil {
+		return err
+	}
+	revokeAccessTokens(rdb, jtis)
+	return nil
+}
+
+// SessionVO 登录会话
+type SessionVO struct {
+	model.UserSession
+	Current bool `json:"current"` // 是否为发起请求的会话
+}
+
+// sessionList 查询用户的登录会话, 标记当前请求所在的会话
+func sessionList(c *gin.Context, userId int) {
+	db := GetDB(c)
+	list, err := model.GetUserSessions(db, userId)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
+	var current string
+	if jti := currentTokenId(c); jti != "" {
+		if token, err := model.GetRefreshTokenByJti(db, jti); err == nil {
+			current = token.FamilyId
+		}
+	}
+	data := make([]SessionVO, 0, len(list))
+	for _, s := range list {
+		data = append(data, SessionVO{UserSession: s, Current: s.FamilyId == current})
+	}
+	ReturnSuccess(c, data)
+}
+
+// GetSessions 当前用户的登录会话 (登录设备) 列表
+func (*User) GetSessions(c *gin.Context) {
+	auth, _ := CurrentUserAuth(c)
+	sessionList(c, auth.ID)
+}
+
+// RevokeSession 下线当前用户的一个登录会话 (可以是当前会话)
+func (*User) RevokeSession(c *gin.Context) {
+	auth, _ := CurrentUserAuth(c)
+	revokeSessionById(c, func(s *model.UserSession) bool { return s.UserId == auth.ID })
+}
+
+type SessionQuery struct {
+	UserId int `form:"user_id" binding:"required"`
+}
+
+// GetUserSessions 查询用户的登录会话 (后台)
+func (*User) GetUserSessions(c *gin.Context) {
+	var query SessionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+	sessionList(c, query.UserId)
+}
+
+// RevokeUserSession 下线任意用户的一个登录会话 (后台)
+func (*User) RevokeUserSession(c *gin.Context) {
+	revokeSessionById(c, func(*model.UserSession) bool { return true })
+}
+
+// revokeSessionById 吊销路径参数 id 指定的会话, allow 判断当前用户能否操作该会话
+func revokeSessionById(c *gin.Context, allow func(*model.UserSession) bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	session, err := model.GetUserSession(db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ReturnError(c, global.ErrSessionNotExist, nil)
+			return
+		}
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	// 不属于当前用户的会话与不存在一样处理, 不泄露其他用户的会话
+	if !allow(session) || session.RevokedAt != nil {
+		ReturnError(c, global.ErrSessionNotExist, nil)
+		return
+	}
+
+	if err := revokeUserSession(db, GetRDB(c), session); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	slog.Info("吊销登录会话", "session", session.ID, "user", session.UserId)
+	ReturnSuccess(c, nil)
+}
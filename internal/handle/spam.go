@@ -0,0 +1,73 @@
+package handle
+
+import (
+	"fmt"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/utils/spam"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"time"
+)
+
+// spamPipeline 根据配置组装垃圾内容检测管道
+func spamPipeline(rdb *redis.Client) *spam.Pipeline {
+	conf := global.GetConfig().Spam
+	return spam.NewPipeline(
+		spam.LinkChecker{Max: conf.MaxLinks},
+		spam.DuplicateChecker{RDB: rdb, Window: time.Duration(conf.DuplicateWindow) * time.Second},
+		spam.VelocityChecker{RDB: rdb, Limit: conf.RateLimit, Window: time.Duration(conf.RateWindow) * time.Second},
+		spam.Bayes{RDB: rdb},
+	)
+}
+
+// checkSpam 发布评论/留言前进行垃圾内容检测, 发布频率按可信代理解析出的客户端 IP 统计 (不能通过伪造请求头绕过)
+// 分数达到拒绝阈值时直接返回错误响应 (ok = false); 达到审核阈值时 review = true, 调用方需要将内容转为待审核
+func checkSpam(c *gin.Context, kind, content string, userId int) (review bool, ok bool) {
+	return checkSpamItem(c, spam.Item{
+		Kind:    kind,
+		Content: content,
+		UserId:  userId,
+		IP:      c.ClientIP(),
+	})
+}
+
+// checkSpamEdit 编辑评论/留言时进行垃圾内容检测, previous 为编辑前的内容
+// 编辑不计入发布频率, 只修改了标点、空白等时也不会被当作重复内容
+func checkSpamEdit(c *gin.Context, kind, content, previous string, userId int) (review bool, ok bool) {
+	return checkSpamItem(c, spam.Item{
+		Kind:     kind,
+		Content:  content,
+		UserId:   userId,
+		IP:       c.ClientIP(),
+		Previous: previous,
+	})
+}
+
+// checkSpamItem 执行垃圾内容检测管道, 返回值同 checkSpam
+func checkSpamItem(c *gin.Context, item spam.Item) (review bool, ok bool) {
+	conf := global.GetConfig().Spam
+	if !conf.Enable {
+		return false, true
+	}
+
+	result := spamPipeline(GetRDB(c)).Check(rctx, item)
+	if result.Score > 0 {
+		slog.Info("垃圾内容检测", "kind", item.Kind, "user", item.UserId, "score", result.Score, "reasons", result.Reasons)
+	}
+
+	if result.Score >= conf.RejectScore {
+		ReturnError(c, global.ErrSpam, nil)
+		return false, false
+	}
+	return result.Score >= conf.ReviewScore, true
+}
+
+// trainSpam 将管理员的审核结果 (通过 -> 正常内容, 标记为垃圾内容 -> 垃圾内容) 作为分类器的训练数据
+// 训练失败只记录日志, 不影响审核操作
+func trainSpam(rdb *redis.Client, kind string, id int, content string, isSpam bool) {
+	bayes := spam.Bayes{RDB: rdb}
+	if err := bayes.Train(rctx, fmt.Sprintf("%s:%d", kind, id), content, isSpam); err != nil {
+		slog.Warn("垃圾内容分类器训练失败", "kind", kind, "id", id, "err", err)
+	}
+}
@@ -0,0 +1,232 @@
+package handle
+
+import (
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"gin-blog-server/internal/utils/jwt"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// 未配置时访问令牌和刷新令牌的有效期
+const (
+	defaultAccessExpire  = 15 * time.Minute
+	defaultRefreshExpire = 7 * 24 * time.Hour
+)
+
+func accessExpire() time.Duration {
+	if minutes := global.GetConfig().JWT.AccessExpire; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultAccessExpire
+}
+
+func refreshExpire() time.Duration {
+	if hours := global.GetConfig().JWT.RefreshExpire; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultRefreshExpire
+}
+
+// TokenVO 签发的令牌
+type TokenVO struct {
+	Token        string `json:"token"`         // 访问令牌
+	RefreshToken string `json:"refresh_token"` // 刷新令牌, 只能使用一次
+	ExpiresIn    int64  `json:"expires_in"`    // 访问令牌有效期(秒)
+	jti          string
+	familyId     string
+	expiresAt    time.Time // 刷新令牌过期时间
+}
+
+// issueTokens 签发访问令牌和刷新令牌, familyId 为空时 (登录) 创建新的令牌族
+func issueTokens(db *gorm.DB, userId int, roleIds []int, familyId string) (*TokenVO, error) {
+	conf := global.GetConfig().JWT
+	jti := utils.RandomToken(16)
+	token, err := jwt.GenToken(conf.Secret, conf.Issuer, jti, accessExpire(), userId, roleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if familyId == "" {
+		familyId = utils.RandomToken(16)
+	}
+	refresh := utils.RandomToken(32)
+	expiresAt := time.Now().Add(refreshExpire())
+	err = model.CreateRefreshToken(db, &model.RefreshToken{
+		UserId:    userId,
+		FamilyId:  familyId,
+		TokenHash: utils.SHA256(refresh),
+		AccessJti: jti,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenVO{
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(accessExpire().Seconds()),
+		jti:          jti,
+		familyId:     familyId,
+		expiresAt:    expiresAt,
+	}, nil
+}
+
+// revokeAccessTokens 将访问令牌加入吊销列表, 保留到访问令牌过期为止
+func revokeAccessTokens(rdb *redis.Client, jtis []string) {
+	if len(jtis) == 0 {
+		return
+	}
+	pipe := rdb.Pipeline()
+	for _, jti := range jtis {
+		if jti != "" {
+			pipe.Set(rctx, global.REVOKED_TOKEN+jti, true, accessExpire())
+		}
+	}
+	if _, err := pipe.Exec(rctx); err != nil {
+		slog.Error("吊销访问令牌失败", "err", err)
+	}
+}
+
+// IsTokenRevoked 访问令牌是否已被吊销
+func IsTokenRevoked(rdb *redis.Client, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	n, err := rdb.Exists(rctx, global.REVOKED_TOKEN+jti).Result()
+	return n > 0, err
+}
+
+// currentTokenId 当前请求的访问令牌 id (jti), 优先从 session 中获取, 没有 session 时从 Authorization 中获取
+func currentTokenId(c *gin.Context) string {
+	if jti, _ := sessions.Default(c).Get(global.CTX_TOKEN_ID).(string); jti != "" {
+		return jti
+	}
+	parts := strings.Split(c.Request.Header.Get("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return ""
+	}
+	claims, err := jwt.ParseToken(global.GetConfig().JWT.Secret, parts[1])
+	if err != nil {
+		return ""
+	}
+	return claims.ID
+}
+
+// revokeSession 吊销当前会话的令牌: 当前访问令牌, 以及同一次登录的整个令牌族
+func revokeSession(c *gin.Context) {
+	jti := currentTokenId(c)
+	if jti == "" {
+		return
+	}
+
+	db, rdb := GetDB(c), GetRDB(c)
+	jtis := []string{jti}
+	if token, err := model.GetRefreshTokenByJti(db, jti); err == nil {
+		family, err := model.RevokeTokenFamily(db, token.FamilyId)
+		if err != nil {
+			slog.Error("吊销令牌族失败", "family", token.FamilyId, "err", err)
+		}
+		jtis = append(jtis, family...)
+	}
+	revokeAccessTokens(rdb, jtis)
+}
+
+// revokeUserTokens 吊销用户的全部令牌 (强制下线)
+func revokeUserTokens(db *gorm.DB, rdb *redis.Client, userId int) error {
+	jtis, err := model.RevokeUserTokens(db, userId)
+	if err != nil {
+		return err
+	}
+	revokeAccessTokens(rdb, jtis)
+	return nil
+}
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌 (轮换)
+// 已使用过的刷新令牌再次出现时视为被盗用, 吊销整个令牌族
+//
+//	@Summary		刷新令牌
+//	@Description	使用刷新令牌换取新的访问令牌和刷新令牌, 旧的刷新令牌随即失效
+//	@Tags			UserAuth
+//	@Param			form	body	RefreshTokenReq	true	"刷新令牌"
+//	@Accept			json
+//	@Produce		json
+//	@Success		0	{object}	Response[TokenVO]
+//	@Router			/token/refresh [post]
+func (*UserAuth) RefreshToken(c *gin.Context) {
+	var req RefreshTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db, rdb := GetDB(c), GetRDB(c)
+	token, err := model.GetRefreshTokenByHash(db, utils.SHA256(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ReturnError(c, global.ErrRefreshToken, nil)
+			return
+		}
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		ReturnError(c, global.ErrRefreshToken, nil)
+		return
+	}
+
+	// 已使用的令牌再次使用 (并发刷新时只有一个请求能标记成功), 吊销整个令牌族
+	ok, err := model.UseRefreshToken(db, token.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if !ok {
+		slog.Warn("刷新令牌重复使用, 吊销令牌族", "user", token.UserId, "family", token.FamilyId)
+		jtis, err := model.RevokeTokenFamily(db, token.FamilyId)
+		if err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		revokeAccessTokens(rdb, jtis)
+		ReturnError(c, global.ErrRefreshReused, nil)
+		return
+	}
+
+	auth, err := model.GetUserAuthInfoById(db, token.UserId)
+	if err != nil {
+		ReturnError(c, global.ErrUserNotExist, err)
+		return
+	}
+	if auth.IsDisable {
+		ReturnError(c, global.ErrUserDisabled, nil)
+		return
+	}
+	roleIds, err := model.GetRoleIdsByUserId(db, auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+
+	vo, err := issueTokens(db, auth.ID, roleIds, token.FamilyId)
+	if err != nil {
+		ReturnError(c, global.ErrTokenCreate, err)
+		return
+	}
+	if err := model.RefreshUserSession(db, token.FamilyId, vo.expiresAt); err != nil {
+		slog.Error("更新登录会话失败", "family", token.FamilyId, "err", err)
+	}
+	ReturnSuccess(c, vo)
+}
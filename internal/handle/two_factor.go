@@ -0,0 +1,343 @@
+package handle
+
+import (
+	"errors"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"gin-blog-server/internal/utils"
+	"gin-blog-server/internal/utils/totp"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+/*
+两步验证:
+  - 开启两步验证的用户登录时, 密码验证通过后只返回预认证令牌, 再通过 /login/2fa 提交验证码或恢复码完成登录
+  - 预认证令牌有效期很短, 只保存摘要, 验证码错误次数过多时作废, 需要重新输入密码
+  - 角色要求两步验证但用户尚未开启时, 仍可登录并设置两步验证, 但不能访问后台接口 (见 PermissionCheck)
+  - 关闭两步验证、重新生成恢复码时的验证码错误同样计入登录失败次数, 达到阈值后冷却或锁定
+*/
+
+const (
+	preAuthExpire      = 5 * time.Minute // 预认证令牌有效期
+	preAuthMaxAttempts = 5               // 预认证令牌最多尝试验证码的次数
+	recoveryCodeCount  = 10              // 每次生成的恢复码数量
+)
+
+// issuePreAuthToken 签发预认证令牌, Redis 中保存摘要 => 用户id
+func issuePreAuthToken(rdb *redis.Client, userId int) (string, error) {
+	token := utils.RandomToken(32)
+	key := global.TWO_FACTOR_PREAUTH + utils.SHA256(token)
+	pipe := rdb.TxPipeline()
+	pipe.HSet(rctx, key, "user_id", userId)
+	pipe.Expire(rctx, key, preAuthExpire)
+	_, err := pipe.Exec(rctx)
+	return token, err
+}
+
+// generateRecoveryCodes 生成恢复码 (形如 xxxx-xxxx-xxxx-xxxx), 返回明文和摘要
+func generateRecoveryCodes() (codes, hashes []string) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := utils.RandomToken(8)
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, utils.SHA256(raw))
+	}
+	return codes, hashes
+}
+
+// normalizeRecoveryCode 去掉恢复码中的分隔符和空白, 统一为小写
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// verifySecondFactor 校验验证码 (6 位数字) 或恢复码, 验证码和恢复码都只能使用一次
+func verifySecondFactor(db *gorm.DB, tf *model.TwoFactor, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		counter, ok := totp.Validate(tf.Secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return model.UseTwoFactorCounter(db, tf.UserId, counter)
+	}
+	return model.UseRecoveryCode(db, tf.UserId, utils.SHA256(normalizeRecoveryCode(code)))
+}
+
+type LoginTwoFactorReq struct {
+	PreAuthToken string `json:"pre_auth_token" binding:"required"`
+	Code         string `json:"code" binding:"required"` // 验证码或恢复码
+}
+
+// LoginTwoFactor 登录第二步: 提交两步验证码或恢复码
+//
+//	@Summary		两步验证登录
+//	@Description	密码验证通过后, 使用预认证令牌和验证码 (或恢复码) 完成登录
+//	@Tags			UserAuth
+//	@Param			form	body	LoginTwoFactorReq	true	"预认证令牌和验证码"
+//	@Accept			json
+//	@Produce		json
+//	@Success		0	{object}	Response[LoginVO]
+//	@Router			/login/2fa [post]
+func (*UserAuth) LoginTwoFactor(c *gin.Context) {
+	var req LoginTwoFactorReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db, rdb := GetDB(c), GetRDB(c)
+	key := global.TWO_FACTOR_PREAUTH + utils.SHA256(req.PreAuthToken)
+	uid, err := rdb.HGet(rctx, key, "user_id").Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			ReturnError(c, global.ErrPreAuthToken, nil)
+			return
+		}
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	// 尝试次数过多时作废预认证令牌, 防止暴力猜测验证码
+	attempts, err := rdb.HIncrBy(rctx, key, "attempts", 1).Result()
+	if err != nil {
+		ReturnError(c, global.ErrRedisOp, err)
+		return
+	}
+	if attempts > preAuthMaxAttempts {
+		rdb.Del(rctx, key)
+		ReturnError(c, global.ErrPreAuthToken, nil)
+		return
+	}
+
+	userAuth, err := model.GetUserAuthInfoById(db, uid)
+	if err != nil {
+		ReturnError(c, global.ErrUserNotExist, err)
+		return
+	}
+	if userAuth.IsDisable {
+		ReturnError(c, global.ErrUserDisabled, nil)
+		return
+	}
+	// 验证码错误与密码错误共用失败次数, 防止通过反复登录获取新的预认证令牌来绕过次数限制
+	guard := newLoginGuard(c, userAuth.Username, userAuth)
+	if !guard.checkLocked(c) {
+		return
+	}
+	tf, err := model.GetTwoFactor(db, uid)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if tf == nil || !tf.IsEnabled {
+		ReturnError(c, global.ErrPreAuthToken, nil)
+		return
+	}
+
+	ok, err := verifySecondFactor(db, tf, req.Code)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if !ok {
+		guard.fail(c)
+		ReturnError(c, global.ErrTwoFactorCode, nil)
+		return
+	}
+	// 预认证令牌只能使用一次
+	if n, err := rdb.Del(rctx, key).Result(); err != nil || n == 0 {
+		ReturnError(c, global.ErrPreAuthToken, err)
+		return
+	}
+
+	slog.Info("用户通过两步验证: " + userAuth.Username)
+	completeLogin(c, userAuth)
+}
+
+// TwoFactorVO 当前用户的两步验证状态
+type TwoFactorVO struct {
+	IsEnabled     bool       `json:"is_enabled"`
+	EnabledAt     *time.Time `json:"enabled_at"`
+	RecoveryCodes int64      `json:"recovery_codes"` // 剩余可用的恢复码数量
+	Required      bool       `json:"required"`       // 角色是否要求开启两步验证
+}
+
+// GetTwoFactor 获取当前用户的两步验证状态
+func (*User) GetTwoFactor(c *gin.Context) {
+	db := GetDB(c)
+	auth, _ := CurrentUserAuth(c)
+
+	tf, err := model.GetTwoFactor(db, auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	vo := TwoFactorVO{Required: auth.RequireTwoFactor()}
+	if tf != nil && tf.IsEnabled {
+		vo.IsEnabled, vo.EnabledAt = true, tf.EnabledAt
+		if vo.RecoveryCodes, err = model.CountRecoveryCodes(db, auth.ID); err != nil {
+			ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+	}
+	ReturnSuccess(c, vo)
+}
+
+// TwoFactorSetupVO 新生成的密钥
+type TwoFactorSetupVO struct {
+	Secret string `json:"secret"` // 无法扫码时手动输入
+	URI    string `json:"uri"`    // otpauth:// 链接, 前端渲染为二维码
+}
+
+// SetupTwoFactor 生成两步验证密钥, 使用验证器扫码后调用 EnableTwoFactor 开启
+func (*User) SetupTwoFactor(c *gin.Context) {
+	db := GetDB(c)
+	auth, _ := CurrentUserAuth(c)
+
+	tf, err := model.GetTwoFactor(db, auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if tf != nil && tf.IsEnabled {
+		ReturnError(c, global.ErrTwoFactorOn, nil)
+		return
+	}
+
+	secret := totp.GenerateSecret()
+	if err := model.SaveTwoFactorSecret(db, auth.ID, secret); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, TwoFactorSetupVO{
+		Secret: secret,
+		URI:    totp.URI(global.GetConfig().JWT.Issuer, auth.Username, secret),
+	})
+}
+
+type TwoFactorCodeReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// EnableTwoFactor 提交验证器上的验证码, 开启两步验证, 返回恢复码 (只显示这一次)
+func (*User) EnableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	auth, _ := CurrentUserAuth(c)
+
+	tf, err := model.GetTwoFactor(db, auth.ID)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	if tf == nil {
+		ReturnError(c, global.ErrRequest, "请先生成两步验证密钥")
+		return
+	}
+	if tf.IsEnabled {
+		ReturnError(c, global.ErrTwoFactorOn, nil)
+		return
+	}
+	counter, ok := totp.Validate(tf.Secret, req.Code, time.Now())
+	if !ok {
+		ReturnError(c, global.ErrTwoFactorCode, nil)
+		return
+	}
+
+	codes, hashes := generateRecoveryCodes()
+	if err := model.EnableTwoFactor(db, auth.ID, counter, hashes); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	slog.Info("用户开启两步验证", "user", auth.ID)
+	ReturnSuccess(c, codes)
+}
+
+// DisableTwoFactor 关闭两步验证, 需要验证码或恢复码; 角色要求两步验证时不能关闭
+func (*User) DisableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	auth, _ := CurrentUserAuth(c)
+	if auth.RequireTwoFactor() {
+		ReturnError(c, global.ErrTwoFactorPolicy, nil)
+		return
+	}
+
+	tf, ok := currentTwoFactor(c, db, auth, req.Code)
+	if !ok {
+		return
+	}
+	if err := model.DisableTwoFactor(db, tf.UserId); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	slog.Info("用户关闭两步验证", "user", auth.ID)
+	ReturnSuccess(c, nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码, 需要验证码或恢复码, 旧的恢复码全部作废
+func (*User) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ReturnError(c, global.ErrRequest, err)
+		return
+	}
+
+	db := GetDB(c)
+	auth, _ := CurrentUserAuth(c)
+	tf, ok := currentTwoFactor(c, db, auth, req.Code)
+	if !ok {
+		return
+	}
+
+	codes, hashes := generateRecoveryCodes()
+	if err := model.ReplaceRecoveryCodes(db, tf.UserId, hashes); err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return
+	}
+	ReturnSuccess(c, codes)
+}
+
+// currentTwoFactor 获取已开启的两步验证设置并校验验证码, 失败时直接返回错误响应
+// 验证码错误与登录共用失败次数和锁定 (loginGuard), 防止盗用会话后暴力猜测验证码来关闭两步验证或生成恢复码
+func currentTwoFactor(c *gin.Context, db *gorm.DB, auth *model.UserAuth, code string) (*model.TwoFactor, bool) {
+	userId := auth.ID
+	guard := newLoginGuard(c, auth.Username, auth)
+	if !guard.checkLocked(c) {
+		return nil, false
+	}
+	tf, err := model.GetTwoFactor(db, userId)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return nil, false
+	}
+	if tf == nil || !tf.IsEnabled {
+		ReturnError(c, global.ErrTwoFactorOff, nil)
+		return nil, false
+	}
+	ok, err := verifySecondFactor(db, tf, code)
+	if err != nil {
+		ReturnError(c, global.ErrDbOp, err)
+		return nil, false
+	}
+	if !ok {
+		slog.Warn("两步验证码错误", "user", userId)
+		guard.fail(c)
+		ReturnError(c, global.ErrTwoFactorCode, nil)
+		return nil, false
+	}
+	return tf, true
+}
@@ -3,6 +3,7 @@ package ginblog
 import (
 	"context"
 	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/handle"
 	"gin-blog-server/internal/model"
 	"github.com/glebarez/sqlite"
 	"github.com/redis/go-redis/v9"
@@ -136,6 +137,56 @@ func InitDatabase(conf *global.Config) *gorm.DB {
 	return db
 }
 
+// InitSensitive
+//
+//	@Description:	从数据库加载敏感词词库
+//	@Param			db	body	gorm.DB	true	"数据库连接"
+func InitSensitive(db *gorm.DB) {
+	if err := handle.LoadSensitiveWords(db); err != nil {
+		log.Fatal("敏感词词库加载失败: ", err)
+	}
+	log.Println("敏感词词库加载成功")
+}
+
+// InitReactions
+//
+//	@Description:	Redis 中没有表情回应数据时从数据库重建
+//	@Param			db	body	gorm.DB	true	"数据库连接"
+//	@Param			rdb	body	redis.Client	true	"redis客户端"
+func InitReactions(db *gorm.DB, rdb *redis.Client) {
+	if err := handle.LoadReactions(db, rdb); err != nil {
+		log.Fatal("表情回应数据加载失败: ", err)
+	}
+}
+
+// InitCommentLikes
+//
+//	@Description:	将 Redis 中的评论点赞数同步到数据库, 用于评论按点赞数排序
+//	@Param			db	body	gorm.DB	true	"数据库连接"
+//	@Param			rdb	body	redis.Client	true	"redis客户端"
+func InitCommentLikes(db *gorm.DB, rdb *redis.Client) {
+	if err := handle.SyncCommentLikes(db, rdb); err != nil {
+		log.Fatal("评论点赞数同步失败: ", err)
+	}
+}
+
+// InitDanmaku
+//
+//	@Description:	后台订阅 Redis 弹幕频道, 将新的弹幕推送给本实例上的 WebSocket/SSE 连接
+//	@Param			rdb	body	redis.Client	true	"redis客户端"
+func InitDanmaku(rdb *redis.Client) {
+	go handle.RunDanmakuHub(context.Background(), rdb)
+}
+
+// InitAccountDeletion
+//
+//	@Description:	后台定期删除注销冷静期已结束的账号
+//	@Param			db	body	gorm.DB	true	"数据库连接"
+//	@Param			rdb	body	redis.Client	true	"redis客户端"
+func InitAccountDeletion(db *gorm.DB, rdb *redis.Client) {
+	go handle.RunAccountDeletion(context.Background(), db, rdb)
+}
+
 // InitRedis
 //
 //	@Description:	初始化redis客户端并测试连接
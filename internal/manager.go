@@ -22,6 +22,10 @@ var (
 	commentAPI      handle.Comment      // 评论
 	messageAPI      handle.Message      // 留言
 	linkAPI         handle.Link         // 友链
+	sensitiveAPI    handle.Sensitive    // 敏感词
+	moderationAPI   handle.Moderation   // 审核队列
+	reportAPI       handle.Report       // 举报
+	banAPI          handle.Ban          // 封禁
 	resourceAPI     handle.Resource     // 资源
 	operationLogAPI handle.OperationLog // 操作日志
 	uploadAPI       handle.Upload       // 文件上传
@@ -50,10 +54,23 @@ func registerBaseHandler(r *gin.Engine) {
 	base := r.Group("/api")
 
 	// TODO: 登录, 注册 记录日志
-	base.POST("/login", userAuthAPI.Login)            // 登录
-	base.POST("/register", userAuthAPI.Register)      // 注册
-	base.GET("/email/verify", userAuthAPI.VerifyCode) // 邮箱验证
-	base.GET("/logout", userAuthAPI.Logout)           // 登出
+	base.POST("/login", userAuthAPI.Login)                    // 登录
+	base.POST("/login/2fa", userAuthAPI.LoginTwoFactor)       // 登录 (两步验证)
+	base.POST("/register", userAuthAPI.Register)              // 注册
+	base.GET("/email/verify", userAuthAPI.VerifyCode)         // 邮箱验证
+	base.POST("/email/resend", userAuthAPI.ResendVerifyEmail) // 重新发送注册验证邮件
+	base.GET("/logout", userAuthAPI.Logout)                   // 登出
+	base.POST("/token/refresh", userAuthAPI.RefreshToken)     // 刷新令牌
+	base.POST("/password/forgot", userAuthAPI.ForgotPassword) // 忘记密码 (发送重置邮件)
+	base.POST("/password/reset", userAuthAPI.ResetPassword)   // 重置密码
+	base.POST("/email/confirm", userAuthAPI.ConfirmEmail)     // 确认修改邮箱
+
+	base.GET("/oauth/providers", userAuthAPI.GetOAuthProviders)      // 已配置的第三方登录方式
+	base.GET("/oauth/:provider/login", userAuthAPI.OAuthLogin)       // 第三方登录 (跳转到授权页面)
+	base.GET("/oauth/:provider/callback", userAuthAPI.OAuthCallback) // 第三方登录回调
+	base.POST("/oauth/login", userAuthAPI.OAuthExchange)             // 第三方登录 (使用一次性登录码换取令牌)
+
+	base.GET("/file/private/:key", uploadAPI.GetPrivateFile) // 私有文件访问(签名链接)
 	// TODO: 博客信息
 	base.POST("/report", blogInfoAPI.Report)
 	base.GET("/config", blogInfoAPI.GetConfigMap)
@@ -71,7 +88,9 @@ func registerAdminHandler(r *gin.Engine) {
 	auth.Use(middleware.ListenOnline())
 
 	auth.GET("/home", blogInfoAPI.GetHomeInfo)
-	auth.POST("/upload", uploadAPI.UploadFile) // 文件上传
+	auth.POST("/upload", middleware.BanCheck(), uploadAPI.UploadFile) // 文件上传
+	auth.DELETE("/upload", uploadAPI.DeleteFile)                      // 删除文件(释放引用)
+	auth.GET("/upload/sign", uploadAPI.SignFile)                      // 私有文件签名链接
 
 	// 用户模块
 	user := auth.Group("/user")
@@ -84,6 +103,8 @@ func registerAdminHandler(r *gin.Engine) {
 		user.PUT("/current/password", userAPI.UpdateCurrentPassword) // 修改当前用户密码
 		user.GET("/online", userAPI.GetOnlineList)                   // 获取在线用户
 		user.POST("/offline/:id", userAPI.ForceOffline)              // 强制用户下线
+		user.GET("/session/list", userAPI.GetUserSessions)           // 用户登录会话列表
+		user.DELETE("/session/:id", userAPI.RevokeUserSession)       // 下线用户的一个登录会话
 	}
 	//博客设置
 	setting := auth.Group("/setting")
@@ -157,6 +178,35 @@ func registerAdminHandler(r *gin.Engine) {
 		link.POST("", linkAPI.SaveOrUpdate) // 新增/编辑友链
 		link.DELETE("", linkAPI.Delete)     // 删除友链
 	}
+	// 敏感词模块
+	sensitive := auth.Group("/sensitive")
+	{
+		sensitive.GET("/list", sensitiveAPI.GetList)   // 敏感词词库列表
+		sensitive.POST("", sensitiveAPI.SaveOrUpdate)  // 新增/编辑敏感词词库
+		sensitive.DELETE("", sensitiveAPI.Delete)      // 删除敏感词词库
+		sensitive.POST("/reload", sensitiveAPI.Reload) // 重新加载敏感词词库
+	}
+	// 审核模块
+	moderation := auth.Group("/moderation")
+	{
+		moderation.GET("/list", moderationAPI.GetList)   // 审核队列
+		moderation.PUT("", moderationAPI.Moderate)       // 批量审核
+		moderation.GET("/log", moderationAPI.GetLogList) // 审核记录
+	}
+	// 举报模块
+	report := auth.Group("/report")
+	{
+		report.GET("/list", reportAPI.GetList)     // 举报列表
+		report.GET("/detail", reportAPI.GetDetail) // 举报明细
+		report.PUT("/dismiss", reportAPI.Dismiss)  // 驳回举报
+	}
+	// 封禁模块
+	ban := auth.Group("/ban")
+	{
+		ban.GET("/list", banAPI.GetList)  // 封禁列表
+		ban.POST("", banAPI.Add)          // 新增封禁
+		ban.PUT("/revoke", banAPI.Revoke) // 解除封禁
+	}
 	// 资源模块
 	resource := auth.Group("/resource")
 	{
@@ -189,19 +239,51 @@ func registerBlogHandler(r *gin.Engine) {
 	base.GET("/home", frontAPI.GetHomeInfo)  //前台首页
 	base.GET("/page", pageAPI.GetList)
 
+	base.GET("/captcha", frontAPI.GetCaptcha)                                     // 图片验证码
+	base.POST("/comment/guest", middleware.BanCheck(), frontAPI.SaveGuestComment) // 游客评论
+	base.POST("/message/guest", middleware.BanCheck(), frontAPI.SaveGuestMessage) // 游客留言
+	base.GET("/message/ws", frontAPI.DanmakuWebSocket)                            // 实时弹幕 (WebSocket)
+	base.GET("/message/stream", frontAPI.DanmakuStream)                           // 实时弹幕 (SSE)
+	base.GET("/user/:id", frontAPI.GetUserProfile)                                // 用户主页
+	base.GET("/author/list", frontAPI.GetAuthorList)                              // 作者列表
+	base.GET("/unsubscribe", userAPI.UnsubscribePage)                             // 邮件退订确认页面
+	base.POST("/unsubscribe", userAPI.Unsubscribe)                                // 邮件客户端一键退订 (RFC 8058)
+
 	//需要登录
 	base.Use(middleware.JWTAuth())
 	{
 		base.GET("/download/:id", uploadAPI.DownloadFile)
-		base.HEAD("/download/:id", uploadAPI.DownloadFile) //文件下载
-		base.POST("/upload", uploadAPI.UploadFile)         // 文件上传
-		base.GET("/user/info", userAPI.GetInfo)            // 根据 Token 获取用户信息
-		base.PUT("/user/info", userAPI.UpdateCurrent)      // 根据 Token 更新当前用户信息
-
-		base.POST("/comment", frontAPI.SaveComment)                 // 前台新增评论
-		base.GET("/comment/like/:comment_id", frontAPI.LikeComment) // 前台点赞评论
-		base.POST("/message", frontAPI.SaveMessage)                 // 前台新增留言
-		base.GET("/article/like/:article_id", frontAPI.LikeArticle) // 前台点赞文章
+		base.HEAD("/download/:id", uploadAPI.DownloadFile)                //文件下载
+		base.POST("/upload", middleware.BanCheck(), uploadAPI.UploadFile) // 文件上传
+		base.GET("/user/info", userAPI.GetInfo)                           // 根据 Token 获取用户信息
+		base.PUT("/user/info", userAPI.UpdateCurrent)                     // 根据 Token 更新当前用户信息
+		base.PUT("/user/notify", userAPI.UpdateNotify)                    // 修改邮件通知设置
+		base.PUT("/user/privacy", userAPI.UpdatePrivacy)                  // 修改是否公开个人主页
+		base.POST("/user/email", userAPI.ChangeEmail)                     // 修改邮箱 (发送确认邮件)
+
+		base.GET("/user/2fa", userAPI.GetTwoFactor)                      // 两步验证状态
+		base.POST("/user/2fa/setup", userAPI.SetupTwoFactor)             // 生成两步验证密钥
+		base.POST("/user/2fa/enable", userAPI.EnableTwoFactor)           // 开启两步验证
+		base.POST("/user/2fa/disable", userAPI.DisableTwoFactor)         // 关闭两步验证
+		base.POST("/user/2fa/recovery", userAPI.RegenerateRecoveryCodes) // 重新生成恢复码
+		base.GET("/user/session", userAPI.GetSessions)                   // 当前用户的登录设备
+		base.DELETE("/user/session/:id", userAPI.RevokeSession)          // 下线一个登录设备
+		base.GET("/user/export", userAPI.ExportData)                     // 导出个人数据
+		base.POST("/user/deletion", userAPI.RequestDeletion)             // 申请注销账号
+		base.DELETE("/user/deletion", userAPI.CancelDeletion)            // 取消注销账号
+
+		base.GET("/notification/list", userAPI.GetNotificationList) // 站内通知列表
+		base.PUT("/notification/read", userAPI.ReadNotifications)   // 站内通知标记已读
+
+		base.POST("/comment", middleware.BanCheck(), frontAPI.SaveComment)                 // 前台新增评论
+		base.GET("/comment/like/:comment_id", middleware.BanCheck(), frontAPI.LikeComment) // 前台点赞评论
+		base.PUT("/comment/:comment_id", middleware.BanCheck(), frontAPI.UpdateComment)    // 前台编辑自己的评论
+		base.DELETE("/comment/:comment_id", frontAPI.DeleteComment)                        // 前台删除自己的评论
+		base.POST("/message", middleware.BanCheck(), frontAPI.SaveMessage)                 // 前台新增留言
+		base.GET("/article/like/:article_id", middleware.BanCheck(), frontAPI.LikeArticle) // 前台点赞文章
+		base.POST("/reaction", middleware.BanCheck(), frontAPI.ToggleReaction)             // 前台文章/评论表情回应 (切换)
+		base.POST("/link/apply", middleware.BanCheck(), frontAPI.ApplyLink)                // 前台申请友链
+		base.POST("/report/content", frontAPI.ReportContent)                               // 前台举报评论/留言
 	}
 
 	category := base.Group("/category")
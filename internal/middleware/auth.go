@@ -8,6 +8,7 @@ import (
 	"gin-blog-server/internal/utils/jwt"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 	"log/slog"
 	"strings"
@@ -19,6 +20,7 @@ import (
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		db := c.MustGet(global.CTX_DB).(*gorm.DB)
+		rdb := c.MustGet(global.CTX_RDB).(*redis.Client)
 
 		// 系统管理的资源需要进行用户鉴权，其他资源不需要鉴权
 		// TODO: 其实可以要所有的资源都需要鉴权，不然新资源有时候会有 bug
@@ -48,14 +50,30 @@ func JWTAuth() gin.HandlerFunc {
 		session := sessions.Default(c)
 		userAuthId := session.Get(global.CTX_USER_AUTH)
 		if userAuthId != nil {
+			// 会话对应的令牌已被吊销 (退出登录、强制下线等), 会话同时失效
+			jti, _ := session.Get(global.CTX_TOKEN_ID).(string)
+			if revoked, err := handle.IsTokenRevoked(rdb, jti); err != nil || revoked {
+				session.Delete(global.CTX_USER_AUTH)
+				session.Delete(global.CTX_TOKEN_ID)
+				session.Save()
+				handle.ReturnError(c, global.ErrTokenRevoked, err)
+				return
+			}
+
 			// 获取用户信息
 			user, err := model.GetUserAuthInfoById(db, userAuthId.(int))
 			if err != nil {
 				handle.ReturnError(c, global.ErrUserNotExist, err)
 				return
 			}
+			// 被禁用的用户不能继续使用已登录的会话
+			if user.IsDisable {
+				handle.ReturnError(c, global.ErrUserDisabled, nil)
+				return
+			}
 			slog.Debug("[middleware-JWTAuth] user auth exist, do session")
 			c.Set(global.CTX_USER_AUTH, user)
+			handle.TouchSession(db, rdb, jti)
 		} else {
 			//if true {
 			slog.Debug("[middleware-JWTAuth] user auth not exist, do jwt auth")
@@ -84,20 +102,32 @@ func JWTAuth() gin.HandlerFunc {
 				return
 			}
 
+			// 判断 token 已经被吊销
+			if revoked, err := handle.IsTokenRevoked(rdb, claims.ID); err != nil || revoked {
+				handle.ReturnError(c, global.ErrTokenRevoked, err)
+				return
+			}
+
 			// 获取用户信息
 			user, err := model.GetUserAuthInfoById(db, claims.UserId)
 			if err != nil {
 				handle.ReturnError(c, global.ErrUserNotExist, err)
 				return
 			}
+			if user.IsDisable {
+				handle.ReturnError(c, global.ErrUserDisabled, nil)
+				return
+			}
 
 			// session 设置
 			session := sessions.Default(c)
 			session.Set(global.CTX_USER_AUTH, claims.UserId)
+			session.Set(global.CTX_TOKEN_ID, claims.ID)
 			session.Save()
 
 			// gin context
 			c.Set(global.CTX_USER_AUTH, user)
+			handle.TouchSession(db, rdb, claims.ID)
 		}
 		// FIXME: 前后台 session 混乱, 暂时无法将用户信息挂载在 gin context 缓存(qpy:未将session信息设置于缓存中存储)
 
@@ -118,6 +148,18 @@ func PermissionCheck() gin.HandlerFunc {
 			handle.ReturnError(c, global.ErrUserNotExist, err)
 			return
 		}
+		// 角色要求两步验证时, 未开启两步验证的用户不能访问后台接口
+		if auth.RequireTwoFactor() {
+			enabled, err := model.IsTwoFactorEnabled(db, auth.ID)
+			if err != nil {
+				handle.ReturnError(c, global.ErrDbOp, err)
+				return
+			}
+			if !enabled {
+				handle.ReturnError(c, global.ErrTwoFactorPolicy, nil)
+				return
+			}
+		}
 		if !auth.IsSuper {
 			slog.Debug("[middleware-PermissionCheck]: super admin no need to check, pass!")
 			return
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/handle"
+	"gin-blog-server/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net"
+	"strings"
+	"time"
+)
+
+// BanCheck 封禁检查, 用于评论、留言、点赞、上传等互动接口
+// 先检查请求 IP 是否在封禁的 IP/网段中, 再检查已登录用户是否被禁用或封禁
+// 请求 IP 使用 c.ClientIP(): 只有来自信任的代理 (Server.TrustedProxies) 的请求才读取 X-Forwarded-For 等请求头, 客户端无法伪造
+func BanCheck() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := c.MustGet(global.CTX_DB).(*gorm.DB)
+
+		bans, err := handle.ActiveIPBans(db)
+		if err != nil {
+			handle.ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		if ip := net.ParseIP(c.ClientIP()); ip != nil {
+			now := time.Now()
+			for _, ban := range bans {
+				if ban.Active(now) && matchIP(ban.IP, ip) {
+					handle.ReturnError(c, global.ErrIPBanned, banMessage(&ban))
+					return
+				}
+			}
+		}
+
+		// 游客接口未经过 JWTAuth, 只有 session 中有登录信息时才检查用户
+		auth, err := handle.CurrentUserAuth(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+		if auth.IsDisable {
+			handle.ReturnError(c, global.ErrUserDisabled, nil)
+			return
+		}
+		ban, err := model.GetActiveUserBan(db, auth.ID)
+		if err != nil {
+			handle.ReturnError(c, global.ErrDbOp, err)
+			return
+		}
+		if ban != nil {
+			handle.ReturnError(c, global.ErrUserBanned, banMessage(ban))
+			return
+		}
+		c.Next()
+	}
+}
+
+// matchIP 判断 IP 是否属于封禁的 IP 或 CIDR 网段
+func matchIP(banned string, ip net.IP) bool {
+	if strings.Contains(banned, "/") {
+		_, ipNet, err := net.ParseCIDR(banned)
+		return err == nil && ipNet.Contains(ip)
+	}
+	target := net.ParseIP(banned)
+	return target != nil && target.Equal(ip)
+}
+
+// banMessage 封禁提示: 到期时间和原因
+func banMessage(ban *model.Ban) string {
+	msg := "永久封禁"
+	if ban.ExpiresAt != nil {
+		msg = "封禁至 " + ban.ExpiresAt.Format("2006-01-02 15:04")
+	}
+	if ban.Reason != "" {
+		msg += ", 原因: " + ban.Reason
+	}
+	return msg
+}
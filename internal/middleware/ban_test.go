@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"encoding/json"
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBanCheck(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{NamingStrategy: schema.NamingStrategy{SingularTable: true}})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&model.Ban{}))
+	assert.Nil(t, model.CreateBan(db, &model.Ban{Type: model.BAN_IP, IP: "203.0.113.5"}))
+	assert.Nil(t, model.CreateBan(db, &model.Ban{Type: model.BAN_IP, IP: "10.0.0.0/8"}))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	assert.Nil(t, r.SetTrustedProxies(nil))
+	r.Use(WithGormDB(db), WithCookieStore("test", "secret"))
+	r.GET("/ping", BanCheck(), func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	// 返回业务状态码, 通过检查时为 -1
+	request := func(remoteAddr string, header map[string]string) int {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() == "pong" {
+			return -1
+		}
+		var resp struct{ Code int }
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Code
+	}
+
+	banned := global.ErrIPBanned.Code()
+	// 直接连接的 RemoteAddr 为 "ip:port"
+	assert.Equal(t, banned, request("203.0.113.5:52100", nil))
+	assert.Equal(t, banned, request("10.1.2.3:52100", nil))
+	assert.Equal(t, banned, request("[::ffff:203.0.113.5]:52100", nil))
+	// 被封禁的客户端伪造请求头
+	assert.Equal(t, banned, request("203.0.113.5:52100", map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.1"}))
+	// 未封禁的客户端不能通过伪造请求头被误封
+	assert.Equal(t, -1, request("198.51.100.7:52100", map[string]string{"X-Forwarded-For": "203.0.113.5", "X-Real-IP": "203.0.113.5"}))
+}
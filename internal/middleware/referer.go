@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"gin-blog-server/internal/global"
+	"gin-blog-server/internal/handle"
+	"github.com/gin-gonic/gin"
+	"net/url"
+	"strings"
+)
+
+// RefererCheck 防盗链校验
+// 只允许来源域名在白名单中的请求引用公开文件, 白名单为空时不做校验
+// 没有 Referer 的请求 (直接在浏览器中打开, 部分客户端) 放行
+func RefererCheck(allowed []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(allowed) == 0 {
+			c.Next()
+			return
+		}
+		referer := c.Request.Referer()
+		if referer == "" {
+			c.Next()
+			return
+		}
+		u, err := url.Parse(referer)
+		if err != nil || !matchHost(u.Hostname(), allowed) {
+			handle.ReturnError(c, global.ErrFileReferer, referer)
+			return
+		}
+		c.Next()
+	}
+}
+
+// matchHost 判断 host 是否在白名单中, 支持 "*.example.com" 形式的通配子域名 (同时匹配 example.com 本身)
+func matchHost(host string, allowed []string) bool {
+	host = strings.ToLower(host)
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == host {
+			return true
+		}
+		if strings.HasPrefix(a, "*.") && (host == a[2:] || strings.HasSuffix(host, a[1:])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMatchHost(t *testing.T) {
+	allowed := []string{"blog.example.com", "*.example.org"}
+
+	assert.True(t, matchHost("blog.example.com", allowed))
+	assert.True(t, matchHost("BLOG.example.com", allowed))
+	assert.False(t, matchHost("example.com", allowed))
+	assert.False(t, matchHost("evil.blog.example.com", allowed))
+
+	// 通配子域名同时匹配主域名
+	assert.True(t, matchHost("example.org", allowed))
+	assert.True(t, matchHost("img.example.org", allowed))
+	assert.True(t, matchHost("a.b.example.org", allowed))
+	assert.False(t, matchHost("badexample.org", allowed))
+	assert.False(t, matchHost("example.org.evil.com", allowed))
+}
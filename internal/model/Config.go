@@ -1,6 +1,9 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"strconv"
+)
 
 // Config @Description: GORM配置模型
 type Config struct {
@@ -88,3 +91,12 @@ func GetConfigBool(db *gorm.DB, key string) bool {
 	}
 	return val == "true"
 }
+
+// GetConfigInt 获取整数配置, 未配置或格式错误时返回默认值
+func GetConfigInt(db *gorm.DB, key string, def int) int {
+	val, err := strconv.Atoi(GetConfig(db, key))
+	if err != nil {
+		return def
+	}
+	return val
+}
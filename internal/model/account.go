@@ -0,0 +1,156 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+/*
+注销账号:
+  - 用户申请注销后记录冷静期结束时间 (delete_at), 冷静期内可以取消; 到期后由后台任务删除账号
+  - 评论和留言不删除, 只去掉作者信息 (显示为已注销用户), 保证评论树和回复关系完整
+  - 删除用户的认证信息、用户信息、角色关联, 以及令牌、会话、两步验证、第三方账号、通知、表情回应、举报等个人数据
+  - 上传的文件可能被其他内容引用 (按内容去重), 只去掉上传者
+  - 发表的文章保留, 去掉作者 (user_id 置为 0)
+*/
+
+// DELETED_USER_NICKNAME 已注销用户的评论/留言显示的昵称
+const DELETED_USER_NICKNAME = "已注销用户"
+
+// ScheduleUserDeletion 申请注销账号, 到 at 时删除; 已经申请过时返回 false
+func ScheduleUserDeletion(db *gorm.DB, userAuthId int, at time.Time) (bool, error) {
+	result := db.Model(&UserAuth{}).
+		Where("id = ? AND delete_at IS NULL", userAuthId).
+		Update("delete_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CancelUserDeletion 取消注销账号; 没有申请过时返回 false
+func CancelUserDeletion(db *gorm.DB, userAuthId int) (bool, error) {
+	result := db.Model(&UserAuth{}).
+		Where("id = ? AND delete_at IS NOT NULL", userAuthId).
+		Update("delete_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetDueUserDeletions 冷静期已结束、需要删除的账号 (user_auth_id), 超级管理员不会被删除
+func GetDueUserDeletions(db *gorm.DB, now time.Time) (ids []int, err error) {
+	result := db.Model(&UserAuth{}).
+		Where("delete_at IS NOT NULL AND delete_at <= ? AND is_super = ?", now, false).
+		Pluck("id", &ids)
+	return ids, result.Error
+}
+
+// UserExport 导出的用户个人数据
+type UserExport struct {
+	Account   *UserAuth  `json:"account"`
+	Comments  []Comment  `json:"comments"`
+	Messages  []Message  `json:"messages"`
+	Reactions []Reaction `json:"reactions"`
+	Uploads   []Media    `json:"uploads"` // 首次由该用户上传的文件
+}
+
+// GetUserExport 查询用户的个人数据: 账号信息、评论 (含编辑历史)、留言、表情回应、上传的文件
+func GetUserExport(db *gorm.DB, userAuthId int) (*UserExport, error) {
+	account, err := GetUserAuthInfoById(db, userAuthId)
+	if err != nil {
+		return nil, err
+	}
+	data := UserExport{Account: account}
+	if err := db.Where("user_id = ?", userAuthId).Preload("Revisions").Order("id").Find(&data.Comments).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userAuthId).Order("id").Find(&data.Messages).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userAuthId).Order("id").Find(&data.Reactions).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userAuthId).Order("id").Find(&data.Uploads).Error; err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// DeleteUserAccount 删除冷静期已结束的账号, likedComments 为用户点赞过的评论 (点赞记录保存在 Redis 中)
+// 在删除前再次检查注销申请, 用户已取消或冷静期未结束时不删除并返回 false
+// 删除后返回令牌记录中签发过的访问令牌 id (令牌记录随账号删除), 由调用方加入吊销列表
+func DeleteUserAccount(db *gorm.DB, userAuthId int, likedComments []int) (deleted bool, jtis []string, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var auth UserAuth
+		result := tx.Where("id = ? AND delete_at IS NOT NULL AND delete_at <= ? AND is_super = ?", userAuthId, time.Now(), false).
+			First(&auth)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 评论和留言保留内容, 去掉作者信息
+		anonymous := map[string]any{
+			"user_id":  0,
+			"nickname": DELETED_USER_NICKNAME,
+			"email":    "",
+			"website":  "",
+			"avatar":   "",
+			"guest_id": "",
+		}
+		if err := tx.Model(&Comment{}).Where("user_id = ?", userAuthId).Updates(anonymous).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Comment{}).Where("reply_user_id = ?", userAuthId).Update("reply_user_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&CommentRevision{}).Where("user_id = ?", userAuthId).Update("user_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Message{}).Where("user_id = ?", userAuthId).Updates(anonymous).Error; err != nil {
+			return err
+		}
+		if len(likedComments) > 0 {
+			err := tx.Model(&Comment{}).Where("id IN ?", likedComments).
+				UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&Media{}).Where("user_id = ?", userAuthId).Update("user_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Article{}).Where("user_id = ?", userAuthId).Update("user_id", 0).Error; err != nil {
+			return err
+		}
+
+		// 令牌记录删除前取出未过期的访问令牌 id
+		err := tx.Model(&RefreshToken{}).Where("user_id = ? AND expires_at > ?", userAuthId, time.Now()).Pluck("access_jti", &jtis).Error
+		if err != nil {
+			return err
+		}
+
+		// 删除个人数据
+		for _, table := range []any{
+			&Mention{}, &Notification{}, &Reaction{}, &Report{},
+			&RefreshToken{}, &UserSession{}, &TwoFactor{}, &RecoveryCode{},
+		} {
+			if err := tx.Where("user_id = ?", userAuthId).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("user_auth_id = ?", userAuthId).Delete(&OAuthAccount{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_auth_id = ?", userAuthId).Delete(&UserAuthRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&UserAuth{}, auth.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&UserInfo{}, auth.UserInfoId).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, jtis, nil
+}
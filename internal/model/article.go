@@ -56,6 +56,9 @@ type BlogArticleVO struct {
 	LikeCount    int64 `json:"like_count"`    // 点赞数量
 	ViewCount    int64 `json:"view_count"`    // 访问数量
 
+	Reactions   map[string]int `gorm:"-" json:"reactions"`    // 各表情回应的数量
+	MyReactions []string       `gorm:"-" json:"my_reactions"` // 当前用户回应过的表情
+
 	LastArticle       ArticlePaginationVO  `gorm:"-" json:"last_article"`       // 上一篇
 	NextArticle       ArticlePaginationVO  `gorm:"-" json:"next_article"`       // 下一篇
 	RecommendArticles []RecommendArticleVO `gorm:"-" json:"recommend_articles"` // 推荐文章
@@ -111,7 +114,7 @@ func GetArticleList(db *gorm.DB, page, size int, title string, isDelete *bool, s
 }
 
 // GetBlogArticleList 前台文章列表（不在回收站并且状态为公开）
-func GetBlogArticleList(db *gorm.DB, page, size, categoryId, tagId int) (data []Article, total int64, err error) {
+func GetBlogArticleList(db *gorm.DB, page, size, categoryId, tagId, userId int) (data []Article, total int64, err error) {
 	db = db.Model(Article{})
 	db = db.Where("is_delete = 0 AND status = 1")
 
@@ -123,6 +126,10 @@ func GetBlogArticleList(db *gorm.DB, page, size, categoryId, tagId int) (data []
 		db = db.Where("id IN (SELECT article_id FROM article_tag WHERE tag_id = ?)", tagId)
 	}
 
+	if userId != 0 {
+		db = db.Where("user_id = ?", userId)
+	}
+
 	db = db.Count(&total)
 	result := db.Preload("Tags").Preload("Category").
 		Order("is_top DESC, id DESC").
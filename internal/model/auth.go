@@ -2,7 +2,6 @@ package model
 
 import (
 	"encoding/json"
-	"gin-blog-server/internal/utils"
 	"gorm.io/gorm"
 	"log/slog"
 	"strconv"
@@ -20,6 +19,7 @@ type UserAuth struct {
 	LastLoginTime *time.Time `json:"last_login_time"`                                   // 最后登录时间
 	IsDisable     bool       `json:"is_disable"`                                        // 是否禁用
 	IsSuper       bool       `json:"is_super"`                                          // 是否是超级管理员
+	DeleteAt      *time.Time `json:"delete_at" gorm:"index;comment:计划注销时间"`             // 申请注销后的冷静期结束时间, 到期后删除账号
 	UserInfoId    int        `json:"user_info_id"`                                      // 用户信息表id
 	UserInfo      *UserInfo  `json:"user_info"`                                         // 用户信息
 	Roles         []*Role    `json:"roles" gorm:"many2many:user_auth_role"`             //
@@ -33,9 +33,10 @@ func (u *UserAuth) MarshalBinary() ([]byte, error) {
 // Role 系统中的角色
 type Role struct {
 	Model
-	Name      string `json:"name" gorm:"unique"`  // 角色名，唯一
-	Label     string `json:"label" gorm:"unique"` // 角色标签，唯一
-	IsDisable bool   `json:"is_disable"`          // 是否禁用
+	Name       string `json:"name" gorm:"unique"`                    // 角色名，唯一
+	Label      string `json:"label" gorm:"unique"`                   // 角色标签，唯一
+	IsDisable  bool   `json:"is_disable"`                            // 是否禁用
+	Require2FA bool   `json:"require_2fa" gorm:"column:require_2fa"` // 是否要求拥有该角色的用户开启两步验证
 
 	Resources []Resource `json:"resources" gorm:"many2many:role_resource"` // 角色拥有的资源，表示与资源的多对多关系
 	Menus     []Menu     `json:"menus" gorm:"many2many:role_menu"`         // 角色拥有的菜单，表示与菜单的多对多关系
@@ -109,6 +110,7 @@ type RoleVO struct {
 	Name        string    `json:"name"`
 	Label       string    `json:"label"`
 	IsDisable   bool      `json:"is_disable"`
+	Require2FA  bool      `json:"require_2fa" gorm:"column:require_2fa"`
 	ResourceIds []int     `json:"resource_ids" gorm:"-"`
 	MenuIds     []int     `json:"menu_ids" gorm:"-"`
 }
@@ -147,7 +149,7 @@ func GetRoleIdsByUserId(db *gorm.DB, userAuthId int) (ids []int, err error) {
 	return ids, result.Error
 }
 
-// CreateNewUser 传入用户名和密码注册新用户
+// CreateNewUser 传入用户名和加密后的密码 (bcrypt) 注册新用户
 func CreateNewUser(db *gorm.DB, username, password string) (*UserAuth, *UserInfo, *UserAuthRole, error) {
 	// 创建userinfo
 	num, err := Count(db, &UserInfo{})
@@ -168,10 +170,9 @@ func CreateNewUser(db *gorm.DB, username, password string) (*UserAuth, *UserInfo
 	}
 
 	// 创建用户权限
-	pass, _ := utils.BcryptHash(password)
 	userAuth := &UserAuth{
 		Username:   username,
-		Password:   pass,
+		Password:   password,
 		UserInfoId: userinfo.ID,
 	}
 	result = db.Create(&userAuth)
@@ -318,7 +319,7 @@ func GetRoleList(db *gorm.DB, num, size int, keyword string) (list []RoleVO, tot
 		db = db.Where("name like ?", "%"+keyword+"%")
 	}
 	db.Count(&total)
-	result := db.Select("id", "name", "label", "created_at", "is_disable").
+	result := db.Select("id", "name", "label", "created_at", "is_disable", "require_2fa").
 		Scopes(Paginate(num, size)).
 		Find(&list)
 	return list, total, result.Error
@@ -347,16 +348,17 @@ func SaveRole(db *gorm.DB, name, label string) error {
 	return result.Error
 }
 
-func UpdateRole(db *gorm.DB, id int, name, label string, isDisable bool, resourceIds, menuIds []int) error {
+func UpdateRole(db *gorm.DB, id int, name, label string, isDisable, require2FA bool, resourceIds, menuIds []int) error {
 	role := Role{
-		Model:     Model{ID: id},
-		Name:      name,
-		Label:     label,
-		IsDisable: isDisable,
+		Model:      Model{ID: id},
+		Name:       name,
+		Label:      label,
+		IsDisable:  isDisable,
+		Require2FA: require2FA,
 	}
 	// 同时更新多个数据库表
 	return db.Transaction(func(tx *gorm.DB) error {
-		if err := db.Model(&role).Select("name", "label", "is_disable").Updates(&role).Error; err != nil {
+		if err := db.Model(&role).Select("name", "label", "is_disable", "require_2fa").Updates(&role).Error; err != nil {
 			return err
 		}
 
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+/*
+封禁:
+  - 账号封禁: 封禁指定用户, 可设置到期时间, 不设置时为永久封禁
+  - IP 封禁: 封禁单个 IP 或 CIDR 网段 (如 192.168.1.0/24), 同样可设置到期时间
+  - 封禁只限制互动接口 (评论、留言、点赞、上传等), 不影响浏览; 账号禁用 (is_disable) 则无法登录
+  - 解除封禁时保留记录, 便于追溯
+*/
+
+// 封禁类型
+const (
+	BAN_USER = "user"
+	BAN_IP   = "ip"
+)
+
+// Ban 封禁记录
+type Ban struct {
+	Model
+	Type       string     `gorm:"type:varchar(10);index;comment:封禁类型" json:"type"`
+	UserId     int        `gorm:"index;comment:被封禁的用户" json:"user_id"`
+	IP         string     `gorm:"type:varchar(50);comment:被封禁的IP或CIDR网段" json:"ip"`
+	Reason     string     `gorm:"type:varchar(255);comment:封禁原因" json:"reason"`
+	ExpiresAt  *time.Time `gorm:"comment:到期时间, 为空时永久封禁" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"comment:解除时间" json:"revoked_at"`
+	OperatorId int        `gorm:"comment:操作人" json:"operator_id"`
+	Operator   string     `gorm:"type:varchar(50);comment:操作人昵称" json:"operator"`
+}
+
+// BanVO 封禁列表
+type BanVO struct {
+	Ban
+	Nickname string `json:"nickname"` // 被封禁用户的昵称
+	IsActive bool   `json:"is_active" gorm:"-"`
+}
+
+// Active 封禁是否生效中 (未解除且未到期)
+func (b *Ban) Active(now time.Time) bool {
+	return b.RevokedAt == nil && (b.ExpiresAt == nil || b.ExpiresAt.After(now))
+}
+
+// activeBan 生效中的封禁
+func activeBan(db *gorm.DB) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+}
+
+// CreateBan 新增封禁
+func CreateBan(db *gorm.DB, ban *Ban) error {
+	return db.Create(ban).Error
+}
+
+// GetActiveUserBan 用户生效中的封禁, 有多条时取到期时间最晚的一条, 没有时返回 nil
+func GetActiveUserBan(db *gorm.DB, userId int) (*Ban, error) {
+	var list []Ban
+	result := activeBan(db.Model(&Ban{})).
+		Where("type = ? AND user_id = ?", BAN_USER, userId).
+		Find(&list)
+	if result.Error != nil || len(list) == 0 {
+		return nil, result.Error
+	}
+	ban := &list[0]
+	for i := range list {
+		if list[i].ExpiresAt == nil {
+			return &list[i], nil
+		}
+		if list[i].ExpiresAt.After(*ban.ExpiresAt) {
+			ban = &list[i]
+		}
+	}
+	return ban, nil
+}
+
+// GetActiveIPBans 全部生效中的 IP 封禁
+func GetActiveIPBans(db *gorm.DB) (list []Ban, err error) {
+	result := activeBan(db.Model(&Ban{})).Where("type = ?", BAN_IP).Find(&list)
+	return list, result.Error
+}
+
+// GetBanList 封禁列表, typ 为空时查询全部类型, active 为 true 时只查询生效中的封禁
+func GetBanList(db *gorm.DB, page, size int, typ string, active bool, keyword string) (list []BanVO, total int64, err error) {
+	db = db.Table("ban").
+		Select("ban.*, COALESCE(user_info.nickname, '') AS nickname").
+		Joins("LEFT JOIN user_auth ON user_auth.id = ban.user_id").
+		Joins("LEFT JOIN user_info ON user_info.id = user_auth.user_info_id")
+	if typ != "" {
+		db = db.Where("ban.type = ?", typ)
+	}
+	if active {
+		db = db.Where("ban.revoked_at IS NULL AND (ban.expires_at IS NULL OR ban.expires_at > ?)", time.Now())
+	}
+	if keyword != "" {
+		db = db.Where("ban.ip LIKE ? OR ban.reason LIKE ? OR user_info.nickname LIKE ?", "%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := db.Order("ban.id DESC").Scopes(Paginate(page, size)).Find(&list)
+	now := time.Now()
+	for i := range list {
+		list[i].IsActive = list[i].Active(now)
+	}
+	return list, total, result.Error
+}
+
+// RevokeBans 解除封禁
+func RevokeBans(db *gorm.DB, ids []int) (int64, error) {
+	result := db.Model(&Ban{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -1,38 +1,151 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"gin-blog-server/internal/utils/markdown"
+	"gorm.io/gorm"
+	"html"
+	"slices"
+	"time"
+)
 
 /*
 如果评论类型是文章，那么 topic_id 就是文章的 id
 如果评论类型是友链，不需要 topic_id
+
+评论树使用物化路径 (materialized path) 存储, 支持多层嵌套:
+  - root_id: 所属的顶级评论 id, 顶级评论的 root_id 为自身 id
+  - path: 从顶级评论到当前评论的 id 路径, 每一级为 8 位十六进制 id + "/", 例如 "0000002a/0000002f/"
+  - path 最长 1000 个字符, 因此嵌套层级最多为 MAX_COMMENT_DEPTH, 超过时拒绝回复
+  - 按 path 排序即为评论树的先序遍历 (先父后子), path 同时可以作为分页游标
+
+评论内容为 Markdown, 返回给前端时有三个字段:
+  - content_html: 渲染并经过白名单过滤的 HTML, 只有这个字段可以按 HTML 展示
+  - content: HTML 转义后的原文, 兼容直接把 content 当作 HTML 展示的旧客户端
+  - content_md: Markdown 原文, 用于编辑, 不能按 HTML 展示
 */
 type Comment struct {
 	Model
-	UserId      int    `json:"user_id"`       // 评论者
-	ReplyUserId int    `json:"reply_user_id"` // 被回复者
-	TopicId     int    `json:"topic_id"`      // 评论的文章
-	ParentId    int    `json:"parent_id"`     // 父评论 被回复的评论
-	Content     string `gorm:"type:varchar(500);not null" json:"content"`
-	Type        int    `gorm:"type:tinyint(1);not null;comment:评论类型(1.文章 2.友链 3.说说)" json:"type"` // 评论类型 1.文章 2.友链 3.说说
-	IsReview    bool   `json:"is_review"`
+	UserId      int        `json:"user_id"`       // 评论者
+	ReplyUserId int        `json:"reply_user_id"` // 被回复者
+	TopicId     int        `json:"topic_id"`      // 评论的文章
+	ParentId    int        `json:"parent_id"`     // 父评论 被回复的评论
+	RootId      int        `gorm:"index;comment:顶级评论" json:"root_id"`
+	Path        string     `gorm:"type:varchar(1000);comment:物化路径" json:"path"`
+	Depth       int        `gorm:"comment:嵌套层级(顶级评论为0)" json:"depth"`
+	LikeCount   int        `gorm:"comment:点赞数" json:"like_count"`
+	Content     string     `gorm:"type:text;not null;comment:Markdown 原文" json:"content_md"`
+	ContentText string     `gorm:"-" json:"content"` // HTML 转义后的 Content, 查询时填充
+	ContentHTML string     `gorm:"type:text;comment:渲染后的 HTML(已经过白名单过滤)" json:"content_html"`
+	Type        int        `gorm:"type:tinyint(1);not null;comment:评论类型(1.文章 2.友链 3.说说)" json:"type"` // 评论类型 1.文章 2.友链 3.说说
+	IsReview    bool       `json:"is_review"`
+	IsReject    bool       `gorm:"comment:审核未通过" json:"is_reject"`
+	IsDelete    bool       `gorm:"comment:是否被删除(作者或审核人员)" json:"is_delete"` // 软删除, 保留占位以保证评论树完整
+	EditedAt    *time.Time `json:"edited_at"`                               // 最后编辑时间
+
+	// 游客评论时的游客信息, 登录用户评论时为空
+	Guest `gorm:"embedded"`
 
 	// Belongs To
 	User      *UserAuth `gorm:"foreignKey:UserId" json:"user"`
 	ReplyUser *UserAuth `gorm:"foreignKey:ReplyUserId" json:"reply_user"`
 	Article   *Article  `gorm:"foreignKey:TopicId" json:"article"`
+
+	// Has Many: 编辑历史, 只在后台评论列表中加载
+	Revisions []CommentRevision `gorm:"foreignKey:CommentId" json:"revisions,omitempty"`
+	// Has Many: 评论中 @ 的用户
+	Mentions []Mention `gorm:"polymorphic:Owner;polymorphicValue:comment" json:"mentions"`
+}
+
+// CommentRevision 评论编辑历史, 每次编辑前保存一份旧内容
+type CommentRevision struct {
+	Model
+	CommentId   int    `gorm:"index;comment:评论id" json:"comment_id"`
+	UserId      int    `gorm:"comment:编辑者" json:"user_id"`
+	Content     string `gorm:"type:text;not null;comment:编辑前的内容(Markdown 原文)" json:"content_md"`
+	ContentText string `gorm:"-" json:"content"` // HTML 转义后的 Content, 查询时填充
+}
+
+// AfterFind 填充转义后的内容, JSON 中的 content 字段可以安全地按 HTML 展示
+func (c *Comment) AfterFind(*gorm.DB) error {
+	c.ContentText = html.EscapeString(c.Content)
+	return nil
+}
+
+// AfterFind 填充转义后的内容, 同 Comment.AfterFind
+func (r *CommentRevision) AfterFind(*gorm.DB) error {
+	r.ContentText = html.EscapeString(r.Content)
+	return nil
+}
+
+// Guest 游客信息, 未登录评论/留言时填写
+// 头像由邮箱生成 (Gravatar), 邮箱不对外展示
+type Guest struct {
+	Nickname string `gorm:"type:varchar(50);comment:游客昵称" json:"nickname,omitempty"`
+	Email    string `gorm:"type:varchar(100);comment:游客邮箱" json:"-"`
+	Website  string `gorm:"type:varchar(255);comment:游客网站" json:"website,omitempty"`
+	Avatar   string `gorm:"type:varchar(255);comment:游客头像" json:"avatar,omitempty"`
+	GuestId  string `gorm:"type:varchar(64);index;comment:游客标识(cookie)" json:"-"`
+}
+
+// CommentViewer 当前浏览评论的用户, 用于展示自己尚未审核的评论
+type CommentViewer struct {
+	UserId  int
+	GuestId string
+}
+
+// owns 判断评论是否属于当前浏览者
+func (v CommentViewer) owns(comment *Comment) bool {
+	if comment.UserId != 0 {
+		return comment.UserId == v.UserId
+	}
+	return comment.GuestId != "" && comment.GuestId == v.GuestId
 }
 
 type CommentVO struct {
 	Comment
-	LikeCount  int         `json:"like_count" gorm:"-"`
-	ReplyCount int         `json:"reply_count" gorm:"-"`
+	ReplyCount int         `json:"reply_count" gorm:"->;-:migration"` // 顶级评论的子孙评论数量, 只读字段, 由查询计算
 	ReplyList  []CommentVO `json:"reply_list" gorm:"-"`
+
+	Reactions   map[string]int `json:"reactions" gorm:"-"`    // 各表情回应的数量
+	MyReactions []string       `json:"my_reactions" gorm:"-"` // 当前用户回应过的表情
+}
+
+// 评论列表排序方式
+const (
+	COMMENT_SORT_NEWEST = "newest" // 最新
+	COMMENT_SORT_OLDEST = "oldest" // 最早
+	COMMENT_SORT_LIKED  = "liked"  // 最多点赞
+)
+
+// commentOrder 顶级评论排序
+func commentOrder(sort string) string {
+	switch sort {
+	case COMMENT_SORT_OLDEST:
+		return "id ASC"
+	case COMMENT_SORT_LIKED:
+		return "like_count DESC, id DESC"
+	default:
+		return "id DESC"
+	}
+}
+
+// MAX_COMMENT_DEPTH 评论最大嵌套层级 (顶级评论为 0), 每级路径 9 个字符, 保证 path 不超过 1000 个字符
+const MAX_COMMENT_DEPTH = 100
+
+var ErrCommentTooDeep = errors.New("评论嵌套层级过深")
+
+// commentPath 根据父评论路径生成物化路径, 固定宽度保证字典序与数值顺序一致
+func commentPath(parentPath string, id int) string {
+	return fmt.Sprintf("%s%08x/", parentPath, id)
 }
 
 // GetArticleCommentCount 获取某篇文章的评论数
 func GetArticleCommentCount(db *gorm.DB, articleId int) (count int64, err error) {
 	result := db.Model(&Comment{}).
-		Where("topic_id = ? AND type = 1 AND is_review = 1", articleId).
+		Where("topic_id = ? AND type = 1 AND is_review = 1 AND is_delete = 0", articleId).
 		Count(&count)
 	return count, result.Error
 }
@@ -60,6 +173,7 @@ func GetCommentList(db *gorm.DB, page, size, typ int, isReview *bool, nickname s
 	result := db.Model(&Comment{}).Count(&total).
 		Preload("User").Preload("User.UserInfo").
 		Preload("Article").
+		Preload("Revisions", func(db *gorm.DB) *gorm.DB { return db.Order("id DESC") }).
 		Order("id DESC").
 		Scopes(Paginate(page, size)).
 		Find(&data)
@@ -69,65 +183,171 @@ func GetCommentList(db *gorm.DB, page, size, typ int, isReview *bool, nickname s
 }
 
 // GetCommentVOList 获取博客评论列表
-func GetCommentVOList(db *gorm.DB, page, size, topic, typ int) (data []CommentVO, total int64, err error) {
-	var list []Comment
-
-	tx := db.Model(&Comment{})
+// 一次查询获取一页顶级评论, 以及每个顶级评论按先序遍历的前 replyLimit 条子孙评论:
+// 通过窗口函数 ROW_NUMBER() OVER (PARTITION BY root_id ORDER BY path) 在每个评论树内编号,
+// 顶级评论的 path 最短排在第一位, 因此保留编号 <= replyLimit + 1 的记录即可
+func GetCommentVOList(db *gorm.DB, page, size, topic, typ int, sort string, replyLimit int, viewer CommentViewer) (data []CommentVO, total int64, err error) {
+	roots := db.Model(&Comment{}).Where("parent_id = 0")
 	if typ != 0 {
-		tx = tx.Where("type = ?", typ)
+		roots = roots.Where("type = ?", typ)
 	}
 	if topic != 0 {
-		tx = tx.Where("topic_id = ?", topic)
+		roots = roots.Where("topic_id = ?", topic)
+	}
+	if err := roots.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
-	//获取顶级评论列表
-	tx.Where("parent_id = 0").Count(&total).
+	rootIds := roots.Select("id").Order(commentOrder(sort)).Scopes(Paginate(page, size))
+	ranked := db.Model(&Comment{}).
+		Select("comment.*, "+
+			"ROW_NUMBER() OVER (PARTITION BY comment.root_id ORDER BY comment.path) AS rn, "+
+			"COUNT(*) OVER (PARTITION BY comment.root_id) - 1 AS reply_count").
+		Joins("JOIN (?) AS r ON comment.root_id = r.id", rootIds)
+
+	var list []CommentVO
+	result := db.Table("(?) AS comment", ranked).
+		Where("rn <= ?", replyLimit+1).
 		Preload("User").Preload("User.UserInfo").
 		Preload("ReplyUser").Preload("ReplyUser.UserInfo").
-		Order("id DESC").
-		Scopes(Paginate(page, size))
-	if err := tx.Find(&list).Error; err != nil {
-		return nil, 0, err
+		Preload("Mentions").
+		Order("path").
+		Find(&list)
+	if result.Error != nil {
+		return nil, 0, result.Error
 	}
-	// 获取顶级评论的回复列表
-	for _, v := range list {
-		var replyList []CommentVO
-
-		tx := db.Model(&Comment{})
-		tx.Where("parent_id = ?", v.ID).
-			Preload("User").Preload("User.UserInfo").
-			Preload("ReplyUser").Preload("ReplyUser.UserInfo").
-			Order("id DESC")
-		if err := tx.Find(&replyList).Error; err != nil {
-			return nil, 0, err
+
+	for i := range list {
+		maskComment(&list[i].Comment, viewer)
+	}
+	data = buildCommentTree(list)
+	sortCommentRoots(data, sort)
+	return data, total, nil
+}
+
+// buildCommentTree 将按 path 排序的评论列表组装成评论树
+// 先序遍历保证父评论一定在子评论之前出现; 父评论不在列表中时 (已被删除), 挂到顶级评论下
+func buildCommentTree(list []CommentVO) []CommentVO {
+	children := make(map[int][]int) // parent_id -> 子评论在 list 中的下标
+	var roots []int
+	index := make(map[int]int, len(list))
+	for i, v := range list {
+		index[v.ID] = i
+	}
+	for i, v := range list {
+		switch {
+		case v.ParentId == 0:
+			roots = append(roots, i)
+		case hasKey(index, v.ParentId):
+			children[v.ParentId] = append(children[v.ParentId], i)
+		default:
+			children[v.RootId] = append(children[v.RootId], i)
 		}
-		for i, _ := range replyList {
-			if !replyList[i].IsReview {
-				replyList[i].Content = "请注意：该评论审核中"
-			}
+	}
+
+	var build func(i int) CommentVO
+	build = func(i int) CommentVO {
+		vo := list[i]
+		if vo.ParentId != 0 {
+			vo.ReplyCount = 0 // 窗口函数按顶级评论统计, 只对顶级评论有意义
 		}
-		if !v.IsReview {
-			v.Content = "请注意：该评论审核中"
+		vo.ReplyList = make([]CommentVO, 0, len(children[vo.ID]))
+		for _, j := range children[vo.ID] {
+			vo.ReplyList = append(vo.ReplyList, build(j))
 		}
+		return vo
+	}
 
-		data = append(data, CommentVO{
-			ReplyCount: len(replyList),
-			Comment:    v,
-			ReplyList:  replyList,
-		})
+	data := make([]CommentVO, 0, len(roots))
+	for _, i := range roots {
+		data = append(data, build(i))
 	}
-	return data, total, nil
+	return data
 }
 
-// GetCommentReplyList 获取评论的回复列表
-func GetCommentReplyList(db *gorm.DB, id, page, size int) (data []Comment, err error) {
-	result := db.Model(&Comment{}).
-		Where(&Comment{ParentId: id}).
+// sortCommentRoots 窗口函数查询结果按 path 排序, 需要按请求的排序方式重新排列顶级评论
+func sortCommentRoots(data []CommentVO, sort string) {
+	slices.SortStableFunc(data, func(a, b CommentVO) int {
+		switch sort {
+		case COMMENT_SORT_OLDEST:
+			return cmp.Compare(a.ID, b.ID)
+		case COMMENT_SORT_LIKED:
+			if a.LikeCount != b.LikeCount {
+				return cmp.Compare(b.LikeCount, a.LikeCount)
+			}
+			return cmp.Compare(b.ID, a.ID)
+		default:
+			return cmp.Compare(b.ID, a.ID)
+		}
+	})
+}
+
+func hasKey(m map[int]int, k int) bool {
+	_, ok := m[k]
+	return ok
+}
+
+// maskComment 已删除或未审核的评论不展示内容
+// 已删除的评论保留占位 (不展示作者), 保证子评论仍然可以正常显示
+// 未审核的评论只对作者本人展示内容
+func maskComment(comment *Comment, viewer CommentViewer) {
+	switch {
+	case comment.IsDelete:
+		comment.Content = "该评论已删除"
+		comment.ContentHTML = comment.Content
+		comment.UserId = 0
+		comment.User = nil
+		comment.Guest = Guest{}
+		comment.Mentions = nil
+	case comment.IsReject && !viewer.owns(comment):
+		comment.Content = "该评论未通过审核"
+		comment.ContentHTML = comment.Content
+	case !comment.IsReview && !viewer.owns(comment):
+		comment.Content = "请注意：该评论审核中"
+		comment.ContentHTML = comment.Content
+	default:
+		return
+	}
+	comment.ContentText = comment.Content
+}
+
+// GetCommentReplyList 获取评论的子孙回复列表 (游标分页)
+// 按 path 先序遍历返回, cursor 为上一页最后一条回复的 path, 为空时从头开始
+// 返回下一页的游标, 没有更多数据时为空
+func GetCommentReplyList(db *gorm.DB, id int, cursor string, size int, viewer CommentViewer) (data []CommentVO, next string, err error) {
+	var parent Comment
+	if err := db.Select("id", "path").First(&parent, id).Error; err != nil {
+		return nil, "", err
+	}
+	if size <= 0 || size > 100 {
+		size = 10
+	}
+
+	var list []Comment
+	tx := db.Model(&Comment{}).
+		Where("path LIKE ? AND id <> ?", parent.Path+"%", parent.ID).
 		Preload("User").Preload("User.UserInfo").
-		Order("id DESC").
-		Scopes(Paginate(page, size)).
-		Find(&data)
-	return data, result.Error
+		Preload("ReplyUser").Preload("ReplyUser.UserInfo").
+		Preload("Mentions").
+		Order("path").
+		Limit(size + 1) // 多查询一条, 判断是否还有下一页
+	if cursor != "" {
+		tx = tx.Where("path > ?", cursor)
+	}
+	if err := tx.Find(&list).Error; err != nil {
+		return nil, "", err
+	}
+
+	if len(list) > size {
+		list = list[:size]
+		next = list[size-1].Path
+	}
+	data = make([]CommentVO, 0, len(list))
+	for _, v := range list {
+		maskComment(&v, viewer)
+		data = append(data, CommentVO{Comment: v})
+	}
+	return data, next, nil
 }
 
 // AddComment 新增评论
@@ -139,12 +359,13 @@ func AddComment(db *gorm.DB, userId, typ, topicId int, content string, isReview
 		Type:     typ,
 		IsReview: isReview,
 	}
-	result := db.Create(&comment)
-	return &comment, result.Error
+	err := createComment(db, &comment, nil)
+	return &comment, err
 }
 
-// ReplyComment 回复评论
-func ReplyComment(db *gorm.DB, userId, replyUserId, parentId int, content string, isReview bool) (*Comment, error) {
+// ReplyComment 回复评论, 可以回复 MAX_COMMENT_DEPTH 层以内的任意评论
+// 被回复者为父评论的作者, 不接受客户端指定, 防止冒充回复任意用户
+func ReplyComment(db *gorm.DB, userId, parentId int, content string, isReview bool) (*Comment, error) {
 	var parent Comment
 	result := db.First(&parent, parentId)
 	if result.Error != nil {
@@ -152,14 +373,182 @@ func ReplyComment(db *gorm.DB, userId, replyUserId, parentId int, content string
 	}
 
 	comment := Comment{
-		UserId:      userId,
-		Content:     content,
-		ReplyUserId: replyUserId,
-		ParentId:    parentId,
-		IsReview:    isReview,
-		TopicId:     parent.TopicId, // 主题和父评论一样
-		Type:        parent.Type,    // 类型和父评论一样
-	}
-	result = db.Create(&comment)
+		UserId:   userId,
+		Content:  content,
+		IsReview: isReview,
+	}
+	err := createComment(db, &comment, &parent)
+	return &comment, err
+}
+
+// AddGuestComment 游客评论或回复评论 (parentId 为 0 时为评论文章), 游客评论一律需要审核
+func AddGuestComment(db *gorm.DB, guest Guest, typ, topicId, parentId int, content string) (*Comment, error) {
+	comment := Comment{
+		Guest:    guest,
+		TopicId:  topicId,
+		Content:  content,
+		Type:     typ,
+		IsReview: false,
+	}
+	if parentId == 0 {
+		err := createComment(db, &comment, nil)
+		return &comment, err
+	}
+
+	var parent Comment
+	result := db.First(&parent, parentId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	err := createComment(db, &comment, &parent)
+	return &comment, err
+}
+
+// createComment 新增评论并生成物化路径, parent 为 nil 时为顶级评论
+// 回复超过最大嵌套层级时返回 ErrCommentTooDeep
+func createComment(db *gorm.DB, comment *Comment, parent *Comment) error {
+	if parent != nil {
+		if parent.Depth >= MAX_COMMENT_DEPTH {
+			return ErrCommentTooDeep
+		}
+		comment.ReplyUserId = parent.UserId // 被回复者为父评论的作者 (游客评论为 0)
+		comment.ParentId = parent.ID
+		comment.RootId = parent.RootId
+		comment.Depth = parent.Depth + 1
+		comment.TopicId = parent.TopicId // 主题和父评论一样
+		comment.Type = parent.Type       // 类型和父评论一样
+	}
+	comment.ContentText = html.EscapeString(comment.Content)
+	comment.ContentHTML = markdown.Render(comment.Content)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		// 物化路径依赖自增 id, 插入后再更新
+		if parent == nil {
+			comment.RootId = comment.ID
+			comment.Path = commentPath("", comment.ID)
+		} else {
+			comment.Path = commentPath(parent.Path, comment.ID)
+		}
+		return tx.Model(comment).Select("root_id", "path").Updates(comment).Error
+	})
+}
+
+// GetCommentsByIds 根据 id 批量获取评论
+func GetCommentsByIds(db *gorm.DB, ids []int) (list []Comment, err error) {
+	result := db.Where("id in ?", ids).Find(&list)
+	return list, result.Error
+}
+
+// GetCommentById 根据 id 获取评论
+func GetCommentById(db *gorm.DB, id int) (*Comment, error) {
+	var comment Comment
+	result := db.First(&comment, id)
 	return &comment, result.Error
 }
+
+// UpdateCommentContent 作者编辑评论: 保存编辑前的内容到编辑历史, 再更新评论内容
+func UpdateCommentContent(db *gorm.DB, comment *Comment, content string, isReview bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		revision := CommentRevision{
+			CommentId: comment.ID,
+			UserId:    comment.UserId,
+			Content:   comment.Content,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		comment.Content = content
+		comment.ContentText = html.EscapeString(content)
+		comment.ContentHTML = markdown.Render(content)
+		comment.IsReview = isReview
+		comment.EditedAt = &now
+		return tx.Model(comment).Select("content", "content_html", "is_review", "edited_at").Updates(comment).Error
+	})
+}
+
+// SoftDeleteComment 作者删除评论: 只标记删除, 评论树中保留占位
+func SoftDeleteComment(db *gorm.DB, id int) error {
+	result := db.Model(&Comment{}).Where("id = ?", id).Update("is_delete", true)
+	return result.Error
+}
+
+// SoftDeleteComments 管理员批量删除评论: 与作者删除一样只标记删除, 保留行以保证子评论的 path/root_id 完整
+// 展示时由 maskComment 屏蔽内容和作者, 返回实际删除 (之前未删除) 的评论数
+func SoftDeleteComments(db *gorm.DB, ids []int) (int64, error) {
+	result := db.Model(&Comment{}).Where("id IN ? AND is_delete = ?", ids, false).Update("is_delete", true)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateCommentLikeCount 更新评论点赞数, 用于按点赞数排序
+func UpdateCommentLikeCount(db *gorm.DB, id, delta int) error {
+	result := db.Model(&Comment{}).Where("id = ?", id).
+		UpdateColumn("like_count", gorm.Expr("like_count + ?", delta))
+	return result.Error
+}
+
+// SyncCommentLikeCounts 使用 Redis 中的评论点赞数覆盖数据库中的 like_count
+func SyncCommentLikeCounts(db *gorm.DB, counts map[int]int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for id, count := range counts {
+			if err := tx.Model(&Comment{}).Where("id = ?", id).UpdateColumn("like_count", count).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RebuildCommentPaths 为没有物化路径的历史评论补全 root_id, path, depth
+// 按 id 升序处理, 父评论的 id 一定小于子评论, 处理子评论时父评论路径已经生成
+func RebuildCommentPaths(db *gorm.DB) error {
+	var list []Comment
+	result := db.Select("id", "parent_id").Where("path = '' OR path IS NULL").Order("id").Find(&list)
+	if result.Error != nil || len(list) == 0 {
+		return result.Error
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, v := range list {
+			update := map[string]any{"root_id": v.ID, "path": commentPath("", v.ID), "depth": 0}
+			if v.ParentId != 0 {
+				var parent Comment
+				if err := tx.Select("id", "root_id", "path", "depth").First(&parent, v.ParentId).Error; err == nil {
+					update = map[string]any{
+						"root_id": parent.RootId,
+						"path":    commentPath(parent.Path, v.ID),
+						"depth":   parent.Depth + 1,
+					}
+				}
+			}
+			if err := tx.Model(&Comment{}).Where("id = ?", v.ID).Updates(update).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BackfillCommentHTML 为支持 Markdown 之前的历史评论补全 content_html
+// 历史评论保存时已经做过 HTML 转义, 可以直接作为 content_html 展示; content 还原为原文, 与新评论一致
+func BackfillCommentHTML(db *gorm.DB) error {
+	var list []Comment
+	result := db.Select("id", "content").
+		Where("content_html IS NULL OR content_html = ''").
+		FindInBatches(&list, 500, func(tx *gorm.DB, _ int) error {
+			for _, comment := range list {
+				err := db.Model(&comment).UpdateColumns(map[string]any{
+					"content":      html.UnescapeString(comment.Content),
+					"content_html": comment.Content,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	return result.Error
+}
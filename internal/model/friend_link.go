@@ -8,6 +8,11 @@ type FriendLink struct {
 	Avatar  string `gorm:"type:varchar(255)" json:"avatar"`
 	Address string `gorm:"type:varchar(255)" json:"address"`
 	Intro   string `gorm:"type:varchar(255)" json:"intro"`
+
+	// 前台申请的友链需要审核, 后台添加的友链直接通过
+	UserId   int  `gorm:"comment:申请用户(后台添加为0)" json:"user_id"`
+	IsReview bool `gorm:"default:true" json:"is_review"`
+	IsReject bool `gorm:"comment:审核未通过" json:"is_reject"`
 }
 
 func GetLinkList(db *gorm.DB, num, size int, keyword string) (list []FriendLink, total int64, err error) {
@@ -41,3 +46,30 @@ func SaveOrUpdateLink(db *gorm.DB, id int, name, avatar, address, intro string)
 	}
 	return &link, result.Error
 }
+
+// GetReviewedLinkList 前台友链列表, 只展示审核通过的友链
+func GetReviewedLinkList(db *gorm.DB) (list []FriendLink, err error) {
+	result := db.Where("is_review = ?", true).Order("created_at DESC").Find(&list)
+	return list, result.Error
+}
+
+// ApplyLink 前台申请友链, 等待审核
+func ApplyLink(db *gorm.DB, userId int, name, avatar, address, intro string) (*FriendLink, error) {
+	link := FriendLink{
+		UserId:   userId,
+		Name:     name,
+		Avatar:   avatar,
+		Address:  address,
+		Intro:    intro,
+		IsReview: false,
+	}
+	// is_review 的默认值为 true (后台添加的友链及历史数据), 创建时 false 会被默认值覆盖, 需要再更新一次
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		link.IsReview = false
+		return tx.Model(&link).Update("is_review", false).Error
+	})
+	return &link, err
+}
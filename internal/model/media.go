@@ -0,0 +1,114 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Media 上传文件记录
+// 文件按内容 SHA-256 去重存储, 相同内容只保存一份, 通过引用计数管理生命周期:
+//   - 重复上传相同内容时引用计数 +1, 直接返回已有的访问路径
+//   - 删除时引用计数 -1, 最后一个引用被删除时才真正删除文件
+type Media struct {
+	Model
+	Hash     string `gorm:"uniqueIndex:idx_media_hash;type:varchar(64);not null;comment:文件内容 SHA-256" json:"hash"`
+	Private  bool   `gorm:"uniqueIndex:idx_media_hash;comment:是否私有文件" json:"private"`
+	Key      string `gorm:"type:varchar(255);not null;comment:存储 key" json:"key"`
+	Url      string `gorm:"type:varchar(255);index;comment:访问路径" json:"url"`
+	Name     string `gorm:"type:varchar(255);comment:原始文件名" json:"name"`
+	Size     int64  `gorm:"comment:文件大小(字节)" json:"size"`
+	OssType  string `gorm:"type:varchar(20);comment:存储类型" json:"oss_type"`
+	RefCount int    `gorm:"not null;default:1;comment:引用计数" json:"ref_count"`
+	UserId   int    `gorm:"comment:首次上传者" json:"user_id"` // user_auth_id
+}
+
+// AcquireMedia 根据内容 hash 查找已存在的文件, 找到则引用计数 +1
+// 文件不存在时返回 nil, nil
+func AcquireMedia(db *gorm.DB, hash string, private bool) (*Media, error) {
+	var media Media
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("hash = ? AND private = ?", hash, private).First(&media)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Model(&media).UpdateColumn("ref_count", gorm.Expr("ref_count + ?", 1))
+		if result.Error != nil {
+			return result.Error
+		}
+		// 记录在读取之后被 ReleaseMedia 删除, 视为不存在, 由调用方重新上传
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		media.RefCount++
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// CreateMedia 新增文件记录, 初始引用计数为 1
+// 并发上传相同内容时唯一索引会冲突, 此时转为对已有记录增加引用
+func CreateMedia(db *gorm.DB, media *Media) (*Media, error) {
+	media.RefCount = 1
+	if err := db.Create(media).Error; err != nil {
+		exist, acquireErr := AcquireMedia(db, media.Hash, media.Private)
+		if acquireErr != nil || exist == nil {
+			return nil, err
+		}
+		return exist, nil
+	}
+	return media, nil
+}
+
+// GetMediaByUrl 根据访问路径获取公开文件记录
+func GetMediaByUrl(db *gorm.DB, url string) (*Media, error) {
+	var media Media
+	result := db.Where("url = ? AND private = ?", url, false).First(&media)
+	return &media, result.Error
+}
+
+// GetMediaByKey 根据存储 key 获取文件记录
+func GetMediaByKey(db *gorm.DB, key string, private bool) (*Media, error) {
+	var media Media
+	// key 是 MySQL 保留字, 使用 map 条件由 GORM 负责转义列名
+	result := db.Where(map[string]any{"key": key, "private": private}).First(&media)
+	return &media, result.Error
+}
+
+// ReleaseMedia 释放一个文件引用: 引用计数 -1
+// 最后一个引用被释放时删除文件记录, 并返回 removed = true, 由调用方在事务提交后删除实际存储的文件
+func ReleaseMedia(db *gorm.DB, id int) (media *Media, removed bool, err error) {
+	media = &Media{}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 锁定记录, 防止并发的 AcquireMedia 在读取引用计数之后增加引用
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(media, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if media.RefCount <= 1 {
+			// 只在引用计数仍为 1 时删除, 不支持行锁的数据库也不会删除刚被引用的记录
+			result = tx.Where("id = ? AND ref_count <= 1", id).Delete(&Media{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				media.RefCount = 0
+				removed = true
+				return nil
+			}
+		}
+		result = tx.Model(&Media{}).Where("id = ?", id).UpdateColumn("ref_count", gorm.Expr("ref_count - ?", 1))
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.First(media, id).Error
+	})
+	return media, removed, err
+}
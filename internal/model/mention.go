@@ -0,0 +1,94 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"regexp"
+)
+
+// Mention 评论/留言中的 @提及, 保存为结构化引用, 前台根据 user_id 渲染为用户链接
+// 通过 GORM 多态关联挂在 Comment (owner_type = "comment") 与 Message (owner_type = "message") 上
+type Mention struct {
+	Model
+	OwnerID   int    `gorm:"index:idx_mention_owner;comment:评论/留言 id" json:"-"`
+	OwnerType string `gorm:"index:idx_mention_owner;type:varchar(20);comment:comment | message" json:"-"`
+	UserId    int    `gorm:"index;comment:被提及的用户" json:"user_id"` // user_auth_id
+	Nickname  string `gorm:"type:varchar(30);comment:被提及时的昵称" json:"nickname"`
+}
+
+// Mention.OwnerType, 与 Comment.Mentions、Message.Mentions 的 polymorphicValue 一致
+const (
+	MENTION_COMMENT = "comment"
+	MENTION_MESSAGE = "message"
+)
+
+// mentionRegexp 匹配 @昵称, 昵称由字母、数字、下划线、连字符组成 (包括中文)
+var mentionRegexp = regexp.MustCompile(`@([\p{L}\p{N}_-]{1,30})`)
+
+// ParseMentions 解析内容中 @ 的昵称 (去重, 保持出现顺序)
+func ParseMentions(content string) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, m := range mentionRegexp.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// GetUsersByNicknames 根据昵称查询用户, 每个昵称只对应一个用户
+// 昵称须完全一致 (数据库排序规则可能不区分大小写), 不存在或对应多个用户的昵称忽略, 不会通知到其他用户
+func GetUsersByNicknames(db *gorm.DB, nicknames []string) ([]UserAuth, error) {
+	if len(nicknames) == 0 {
+		return nil, nil
+	}
+	var found []UserAuth
+	result := db.Joins("UserInfo").Where("UserInfo.nickname IN ?", nicknames).Find(&found)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	matches := make(map[string][]UserAuth, len(nicknames))
+	for _, user := range found {
+		if user.UserInfo != nil {
+			matches[user.UserInfo.Nickname] = append(matches[user.UserInfo.Nickname], user)
+		}
+	}
+	list := make([]UserAuth, 0, len(nicknames))
+	for _, name := range nicknames {
+		if len(matches[name]) == 1 {
+			list = append(list, matches[name][0])
+		}
+	}
+	return list, nil
+}
+
+// SaveMentions 保存评论/留言中 @ 的用户, 替换原有的记录 (编辑评论时重新解析)
+func SaveMentions(db *gorm.DB, ownerType string, ownerId int, users []UserAuth) ([]Mention, error) {
+	mentions := make([]Mention, 0, len(users))
+	for _, user := range users {
+		mention := Mention{OwnerID: ownerId, OwnerType: ownerType, UserId: user.ID}
+		if user.UserInfo != nil {
+			mention.Nickname = user.UserInfo.Nickname
+		}
+		mentions = append(mentions, mention)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("owner_type = ? AND owner_id = ?", ownerType, ownerId).Delete(&Mention{}).Error; err != nil {
+			return err
+		}
+		if len(mentions) == 0 {
+			return nil
+		}
+		return tx.Create(&mentions).Error
+	})
+	return mentions, err
+}
+
+// GetMentions 获取评论/留言中 @ 的用户
+func GetMentions(db *gorm.DB, ownerType string, ownerId int) (list []Mention, err error) {
+	result := db.Where("owner_type = ? AND owner_id = ?", ownerType, ownerId).Find(&list)
+	return list, result.Error
+}
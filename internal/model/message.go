@@ -11,6 +11,14 @@ type Message struct {
 	IpSource  string `gorm:"type:varchar(255);comment:IP 来源" json:"ipSource"`
 	Speed     int    `gorm:"type:tinyint(1);comment:弹幕速度" json:"speed"`
 	IsReview  bool   `json:"is_review"`
+	IsReject  bool   `gorm:"comment:审核未通过" json:"is_reject"`
+	UserId    int    `gorm:"comment:留言用户(游客为0)" json:"user_id"`
+	Email     string `gorm:"type:varchar(100);comment:游客邮箱" json:"-"`
+	Website   string `gorm:"type:varchar(255);comment:游客网站" json:"website,omitempty"`
+	GuestId   string `gorm:"type:varchar(64);index;comment:游客标识(cookie)" json:"-"`
+
+	// Has Many: 留言中 @ 的用户
+	Mentions []Mention `gorm:"polymorphic:Owner;polymorphicValue:message" json:"mentions"`
 }
 
 func GetMessageList(db *gorm.DB, num, size int, nickname string, isReview *bool) (list []Message, total int64, err error) {
@@ -29,19 +37,38 @@ func GetMessageList(db *gorm.DB, num, size int, nickname string, isReview *bool)
 	return list, total, result.Error
 }
 
+// GetFrontMessageList 前台留言列表: 已审核的留言, 以及当前游客自己尚未审核的留言
+func GetFrontMessageList(db *gorm.DB, guestId string) (list []Message, err error) {
+	db = db.Model(&Message{})
+	if guestId != "" {
+		db = db.Where("is_review = ? OR guest_id = ?", true, guestId)
+	} else {
+		db = db.Where("is_review = ?", true)
+	}
+	result := db.Preload("Mentions").Order("created_at DESC").Scopes(Paginate(1, 1000)).Find(&list)
+	return list, result.Error
+}
+
+// GetMessagesByIds 根据 id 批量获取留言
+func GetMessagesByIds(db *gorm.DB, ids []int) (list []Message, err error) {
+	result := db.Where("id in ?", ids).Find(&list)
+	return list, result.Error
+}
+
 func DeleteMessages(db *gorm.DB, ids []int) (int64, error) {
 	result := db.Where("id in ?", ids).Delete(&Message{})
 	return result.RowsAffected, result.Error
 }
 
 func UpdateMessagesReview(db *gorm.DB, ids []int, isReview bool) (int64, error) {
-	result := db.Model(&Message{}).Where("id in ?", ids).Update("is_review", isReview)
+	result := db.Model(&Message{}).Where("id in ?", ids).Updates(map[string]any{"is_review": isReview, "is_reject": false})
 	return result.RowsAffected, result.Error
 }
 
 // SaveMessage 保存留言功能
-func SaveMessage(db *gorm.DB, nickname, avatar, content, address, source string, speed int, isReview bool) (*Message, error) {
+func SaveMessage(db *gorm.DB, userId int, nickname, avatar, content, address, source string, speed int, isReview bool) (*Message, error) {
 	message := Message{
+		UserId:    userId,
 		Nickname:  nickname,
 		Avatar:    avatar,
 		Content:   content,
@@ -54,3 +81,22 @@ func SaveMessage(db *gorm.DB, nickname, avatar, content, address, source string,
 	result := db.Create(&message)
 	return &message, result.Error
 }
+
+// SaveGuestMessage 保存游客留言, 游客留言一律需要审核
+func SaveGuestMessage(db *gorm.DB, guest Guest, content, address, source string, speed int) (*Message, error) {
+	message := Message{
+		Nickname:  guest.Nickname,
+		Avatar:    guest.Avatar,
+		Email:     guest.Email,
+		Website:   guest.Website,
+		GuestId:   guest.GuestId,
+		Content:   content,
+		IpAddress: address,
+		IpSource:  source,
+		Speed:     speed,
+		IsReview:  false,
+	}
+
+	result := db.Create(&message)
+	return &message, result.Error
+}
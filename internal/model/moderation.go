@@ -0,0 +1,260 @@
+package model
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"html"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+审核队列:
+  - 评论、留言、友链申请统一在一个队列中审核, 可按类型、状态、关键字筛选
+  - 审核状态由 is_review (通过) 和 is_reject (拒绝) 两个字段表示, 都为 false 时为待审核
+  - 每次审核操作 (通过/拒绝/删除) 都记录审核人和原因, 删除时保留内容快照; 状态没有改变的对象 (例如重复通过) 不处理也不记录
+  - 标记为垃圾内容 (spam) 与删除相同, 另外将内容作为垃圾内容分类器的训练数据
+*/
+
+// 审核对象类型
+const (
+	MODERATION_COMMENT = "comment"
+	MODERATION_MESSAGE = "message"
+	MODERATION_LINK    = "link"
+)
+
+// 审核操作
+const (
+	MODERATION_APPROVE = "approve"
+	MODERATION_REJECT  = "reject"
+	MODERATION_DELETE  = "delete"
+	MODERATION_SPAM    = "spam" // 删除并作为垃圾内容训练
+)
+
+// 审核状态, 用于筛选审核队列
+const (
+	MODERATION_PENDING  = "pending"
+	MODERATION_APPROVED = "approved"
+	MODERATION_REJECTED = "rejected"
+)
+
+// ModerationLog 审核记录
+type ModerationLog struct {
+	Model
+	TargetType  string `gorm:"type:varchar(20);index:idx_moderation_target;comment:审核对象类型" json:"target_type"`
+	TargetId    int    `gorm:"index:idx_moderation_target;comment:审核对象id" json:"target_id"`
+	Action      string `gorm:"type:varchar(20);comment:审核操作" json:"action"`
+	Reason      string `gorm:"type:varchar(255);comment:审核原因" json:"reason"`
+	Content     string `gorm:"type:text;comment:审核时的内容快照" json:"content"`
+	ModeratorId int    `gorm:"comment:审核人" json:"moderator_id"`
+	Moderator   string `gorm:"type:varchar(50);comment:审核人昵称" json:"moderator"`
+}
+
+// ModerationItem 审核队列中的一项
+type ModerationItem struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	UserId    int       `json:"user_id"` // 作者, 游客为 0
+	Nickname  string    `json:"nickname"`
+	Content   string    `json:"content"`
+	Url       string    `json:"url"` // 友链申请的网站地址
+	IsReview  bool      `json:"is_review"`
+	IsReject  bool      `json:"is_reject"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ModerationTarget 审核对象的作者信息与内容, 用于通知作者和保存快照
+type ModerationTarget struct {
+	ID       int
+	UserId   int
+	Nickname string
+	Email    string // 游客邮箱
+	Content  string
+}
+
+// moderationQuery 各类审核对象转换为 ModerationItem 的查询
+func moderationQuery(db *gorm.DB, typ string) *gorm.DB {
+	switch typ {
+	case MODERATION_COMMENT:
+		return db.Table("comment").
+			Select("? AS type, comment.id, comment.user_id, COALESCE(NULLIF(comment.nickname, ''), user_info.nickname, '') AS nickname, "+
+				"comment.content, '' AS url, comment.is_review, comment.is_reject, comment.created_at", MODERATION_COMMENT).
+			Joins("LEFT JOIN user_auth ON user_auth.id = comment.user_id").
+			Joins("LEFT JOIN user_info ON user_info.id = user_auth.user_info_id").
+			Where("comment.is_delete = ?", false)
+	case MODERATION_MESSAGE:
+		return db.Table("message").
+			Select("? AS type, id, user_id, nickname, content, '' AS url, is_review, is_reject, created_at", MODERATION_MESSAGE)
+	default:
+		return db.Table("friend_link").
+			Select("? AS type, id, user_id, name AS nickname, intro AS content, address AS url, is_review, is_reject, created_at", MODERATION_LINK)
+	}
+}
+
+// GetModerationList 审核队列, typ 为空时查询全部类型, status 为空时查询全部状态
+func GetModerationList(db *gorm.DB, page, size int, typ, status, keyword string) (list []ModerationItem, total int64, err error) {
+	var queries []any
+	for _, t := range []string{MODERATION_COMMENT, MODERATION_MESSAGE, MODERATION_LINK} {
+		if typ == "" || typ == t {
+			queries = append(queries, moderationQuery(db.Session(&gorm.Session{NewDB: true}), t))
+		}
+	}
+	if len(queries) == 0 {
+		return list, 0, nil
+	}
+
+	// 各子查询再包一层, 兼容不支持 "(SELECT ...) UNION ALL (SELECT ...)" 写法的数据库
+	parts := make([]string, len(queries))
+	for i := range queries {
+		parts[i] = fmt.Sprintf("SELECT * FROM (?) AS t%d", i)
+	}
+	union := db.Raw(strings.Join(parts, " UNION ALL "), queries...)
+
+	db = db.Table("(?) AS q", union)
+	switch status {
+	case MODERATION_PENDING:
+		db = db.Where("is_review = ? AND is_reject = ?", false, false)
+	case MODERATION_APPROVED:
+		db = db.Where("is_review = ?", true)
+	case MODERATION_REJECTED:
+		db = db.Where("is_reject = ?", true)
+	}
+	if keyword != "" {
+		db = db.Where("content LIKE ? OR nickname LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := db.Order("created_at DESC").Scopes(Paginate(page, size)).Find(&list)
+	for i := range list {
+		list[i].Content = moderationContent(list[i].Type, list[i].Content)
+	}
+	return list, total, result.Error
+}
+
+// moderationContent 后台展示的内容: 评论保存的是 Markdown 原文, 转义后与留言 (保存时已转义) 一致
+func moderationContent(typ, content string) string {
+	if typ == MODERATION_COMMENT {
+		return html.EscapeString(content)
+	}
+	return content
+}
+
+// GetModerationTargets 获取审核对象的作者信息与内容
+func GetModerationTargets(db *gorm.DB, typ string, ids []int) (list []ModerationTarget, err error) {
+	switch typ {
+	case MODERATION_COMMENT:
+		err = db.Table("comment").Select("id, user_id, nickname, email, content").Where("id IN ?", ids).Find(&list).Error
+	case MODERATION_MESSAGE:
+		err = db.Table("message").Select("id, user_id, nickname, email, content").Where("id IN ?", ids).Find(&list).Error
+	default:
+		err = db.Table("friend_link").Select("id, user_id, name AS nickname, '' AS email, intro AS content").Where("id IN ?", ids).Find(&list).Error
+	}
+	return list, err
+}
+
+// Moderate 批量审核: 修改审核状态或删除, 并记录审核日志
+// 只处理状态会改变的对象 (例如已经通过的评论再次通过时跳过), 返回这些对象, 调用方只对它们发送通知、训练分类器
+// 评论的删除与作者删除相同, 只标记删除 (保留占位以保证评论树完整); 留言和友链申请直接删除
+func Moderate(db *gorm.DB, typ string, targets []ModerationTarget, action, reason string, moderatorId int, moderator string) ([]ModerationTarget, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, len(targets))
+	for i, t := range targets {
+		ids[i] = t.ID
+	}
+
+	var table any
+	switch typ {
+	case MODERATION_COMMENT:
+		table = &Comment{}
+	case MODERATION_MESSAGE:
+		table = &Message{}
+	default:
+		table = &FriendLink{}
+	}
+
+	var changed []ModerationTarget
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 查询状态会改变的对象, 并锁定到事务结束, 同时审核同一对象时只有一次生效
+		query := tx.Model(table).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids)
+		var updates map[string]any // 为空时直接删除
+		switch {
+		case action == MODERATION_APPROVE:
+			query = query.Where("NOT (is_review = ? AND is_reject = ?)", true, false)
+			updates = map[string]any{"is_review": true, "is_reject": false}
+		case action == MODERATION_REJECT:
+			query = query.Where("NOT (is_review = ? AND is_reject = ?)", false, true)
+			updates = map[string]any{"is_review": false, "is_reject": true}
+		case typ == MODERATION_COMMENT: // MODERATION_DELETE, MODERATION_SPAM
+			query = query.Where("is_delete = ?", false)
+			updates = map[string]any{"is_delete": true}
+		}
+		var changedIds []int
+		if err := query.Pluck("id", &changedIds).Error; err != nil {
+			return err
+		}
+		if len(changedIds) > 0 {
+			var result *gorm.DB
+			if updates != nil {
+				result = tx.Model(table).Where("id IN ?", changedIds).Updates(updates)
+			} else {
+				result = tx.Where("id IN ?", changedIds).Delete(table)
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		// 审核人员已经处理, 相关举报标记为已处理
+		if _, err := HandleReports(tx, typ, ids); err != nil {
+			return err
+		}
+
+		for _, t := range targets {
+			if slices.Contains(changedIds, t.ID) {
+				changed = append(changed, t)
+			}
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+		logs := make([]ModerationLog, len(changed))
+		for i, t := range changed {
+			logs[i] = ModerationLog{
+				TargetType:  typ,
+				TargetId:    t.ID,
+				Action:      action,
+				Reason:      reason,
+				Content:     moderationContent(typ, t.Content),
+				ModeratorId: moderatorId,
+				Moderator:   moderator,
+			}
+		}
+		return tx.Create(&logs).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// GetModerationLogList 审核记录, typ 为空时查询全部类型, targetId 为 0 时查询全部对象
+func GetModerationLogList(db *gorm.DB, page, size int, typ string, targetId int) (list []ModerationLog, total int64, err error) {
+	db = db.Model(&ModerationLog{})
+	if typ != "" {
+		db = db.Where("target_type = ?", typ)
+	}
+	if targetId != 0 {
+		db = db.Where("target_id = ?", targetId)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := db.Order("id DESC").Scopes(Paginate(page, size)).Find(&list)
+	return list, total, result.Error
+}
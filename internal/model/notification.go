@@ -0,0 +1,48 @@
+package model
+
+import "gorm.io/gorm"
+
+// 站内通知类型
+const (
+	NOTIFICATION_MENTION    = "mention"    // 被 @ 提及
+	NOTIFICATION_MODERATION = "moderation" // 审核结果
+)
+
+// Notification 站内通知
+type Notification struct {
+	Model
+	UserId   int    `gorm:"index;comment:接收者" json:"user_id"` // user_auth_id
+	Type     string `gorm:"type:varchar(20);comment:通知类型" json:"type"`
+	SenderId int    `gorm:"comment:触发通知的用户(游客为0)" json:"sender_id"`
+	Title    string `gorm:"type:varchar(100)" json:"title"`
+	Content  string `gorm:"type:varchar(500)" json:"content"`
+	Url      string `gorm:"type:varchar(255);comment:点击通知跳转的页面" json:"url"`
+	IsRead   bool   `json:"is_read"`
+}
+
+func CreateNotification(db *gorm.DB, notification *Notification) error {
+	return db.Create(notification).Error
+}
+
+// GetNotificationList 获取用户的站内通知列表, 同时返回未读数量
+func GetNotificationList(db *gorm.DB, userId, page, size int) (list []Notification, total, unread int64, err error) {
+	db = db.Model(&Notification{}).Where("user_id = ?", userId)
+	if err = db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, 0, err
+	}
+	if err = db.Session(&gorm.Session{}).Where("is_read = ?", false).Count(&unread).Error; err != nil {
+		return nil, 0, 0, err
+	}
+	result := db.Order("id DESC").Scopes(Paginate(page, size)).Find(&list)
+	return list, total, unread, result.Error
+}
+
+// ReadNotifications 将通知标记为已读, ids 为空时全部标记为已读
+func ReadNotifications(db *gorm.DB, userId int, ids []int) (int64, error) {
+	db = db.Model(&Notification{}).Where("user_id = ? AND is_read = ?", userId, false)
+	if len(ids) > 0 {
+		db = db.Where("id IN ?", ids)
+	}
+	result := db.Update("is_read", true)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"gin-blog-server/internal/utils"
+	"gorm.io/gorm"
+)
+
+/*
+第三方账号:
+  - 一个第三方账号 (提供方 + 提供方内的用户标识) 只能关联一个用户, 一个用户可以关联多个第三方账号
+  - 第三方账号首次登录时: 提供方确认已验证的邮箱与已有用户一致则关联该用户, 否则自动创建用户
+*/
+
+// 登录方式 (UserAuth.LoginType)
+const (
+	LOGIN_TYPE_PASSWORD = 0 // 用户名/邮箱 + 密码
+	LOGIN_TYPE_OAUTH    = 1 // 第三方登录自动创建的账号
+)
+
+// OAuthAccount 用户关联的第三方账号
+type OAuthAccount struct {
+	Model
+	UserAuthId int    `gorm:"index;comment:用户id" json:"user_auth_id"`
+	Provider   string `gorm:"type:varchar(20);uniqueIndex:idx_oauth_subject;comment:提供方" json:"provider"`
+	Subject    string `gorm:"type:varchar(191);uniqueIndex:idx_oauth_subject;comment:提供方内的用户标识" json:"-"`
+	Email      string `gorm:"type:varchar(100);comment:第三方账号邮箱" json:"email"`
+	Nickname   string `gorm:"type:varchar(100);comment:第三方账号昵称" json:"nickname"`
+}
+
+// TableName 默认命名规则会生成 o_auth_account
+func (OAuthAccount) TableName() string {
+	return "oauth_account"
+}
+
+// GetOAuthAccount 根据提供方和用户标识获取第三方账号, 没有时返回 nil
+func GetOAuthAccount(db *gorm.DB, provider, subject string) (*OAuthAccount, error) {
+	var account OAuthAccount
+	result := db.Where("provider = ? AND subject = ?", provider, subject).First(&account)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &account, result.Error
+}
+
+// CreateOAuthAccount 关联第三方账号
+func CreateOAuthAccount(db *gorm.DB, account *OAuthAccount) error {
+	return db.Create(account).Error
+}
+
+// CreateOAuthUser 第三方账号首次登录时自动创建用户, 并关联该第三方账号
+// 密码为随机值, 用户可以通过重置密码设置密码 (有邮箱时)
+func CreateOAuthUser(db *gorm.DB, username string, info *UserInfo, account *OAuthAccount) (*UserAuth, error) {
+	var userAuth *UserAuth
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(info).Error; err != nil {
+			return err
+		}
+
+		pass, err := utils.BcryptHash(utils.RandomToken(32))
+		if err != nil {
+			return err
+		}
+		userAuth = &UserAuth{
+			Username:   username,
+			Password:   pass,
+			LoginType:  LOGIN_TYPE_OAUTH,
+			UserInfoId: info.ID,
+		}
+		if err := tx.Create(userAuth).Error; err != nil {
+			return err
+		}
+
+		// 默认身份为游客, 与注册用户一致
+		if err := tx.Create(&UserAuthRole{UserAuthId: userAuth.ID, RoleId: 2}).Error; err != nil {
+			return err
+		}
+
+		account.UserAuthId = userAuth.ID
+		return tx.Create(account).Error
+	})
+	return userAuth, err
+}
+
+// ExistUserNickname 昵称是否已被使用
+func ExistUserNickname(db *gorm.DB, nickname string) (bool, error) {
+	var count int64
+	result := db.Model(&UserInfo{}).Where("nickname = ?", nickname).Count(&count)
+	return count > 0, result.Error
+}
@@ -29,3 +29,8 @@ func GetOperationLogList(db *gorm.DB, num, size int, keyword string) (data []Ope
 	result := db.Order("created_at DESC").Scopes(Paginate(num, size)).Find(&data)
 	return data, total, result.Error
 }
+
+// CreateOperationLog 新增操作日志
+func CreateOperationLog(db *gorm.DB, log *OperationLog) error {
+	return db.Create(log).Error
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+/*
+前台用户主页:
+  - 公开的资料: 昵称、头像、简介、网站、注册时间; 默认不公开, 用户开启公开 (is_public) 前不展示简介、网站、文章、评论和统计数据
+  - 用户发表的公开文章、最近通过审核的评论, 以及文章数、评论数、获得的点赞数
+  - 作者列表: 发表过公开文章的用户, 按文章数排序
+*/
+
+// UserProfile 用户公开的资料
+type UserProfile struct {
+	ID        int       `json:"id"` // user_auth_id
+	Nickname  string    `json:"nickname"`
+	Avatar    string    `json:"avatar"`
+	Intro     string    `json:"intro"`
+	Website   string    `json:"website"`
+	IsPublic  bool      `json:"is_public"`
+	CreatedAt time.Time `json:"created_at"` // 注册时间
+}
+
+// UserStats 用户的统计数据
+type UserStats struct {
+	ArticleCount int64 `json:"article_count"` // 公开文章数
+	CommentCount int64 `json:"comment_count"` // 已审核的评论数
+	LikeCount    int64 `json:"like_count"`    // 文章和评论获得的点赞数
+}
+
+// UserCommentVO 用户主页中的评论
+type UserCommentVO struct {
+	ID           int       `json:"id"`
+	Type         int       `json:"type"`
+	TopicId      int       `json:"topic_id"`
+	ArticleTitle string    `json:"article_title"` // 评论的文章标题, 非文章评论时为空
+	ContentHTML  string    `json:"content_html"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AuthorVO 作者列表
+type AuthorVO struct {
+	UserProfile
+	ArticleCount int64 `json:"article_count"`
+}
+
+// profileQuery 用户资料的查询, 关联 user_auth 与 user_info
+func profileQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("user_auth ua").
+		Select("ua.id, ua.created_at, ui.nickname, ui.avatar, ui.intro, ui.website, ui.is_public").
+		Joins("JOIN user_info ui ON ui.id = ua.user_info_id")
+}
+
+// GetUserProfile 根据 user_auth_id 获取用户公开的资料
+func GetUserProfile(db *gorm.DB, id int) (*UserProfile, error) {
+	var profile UserProfile
+	result := profileQuery(db).Where("ua.id = ?", id).Take(&profile)
+	return &profile, result.Error
+}
+
+// GetUserArticleIds 用户发表的公开文章 id
+func GetUserArticleIds(db *gorm.DB, userId int) (ids []int, err error) {
+	result := db.Model(&Article{}).
+		Where("user_id = ? AND is_delete = 0 AND status = 1", userId).
+		Pluck("id", &ids)
+	return ids, result.Error
+}
+
+// GetUserNewestArticles 用户最新的 n 篇公开文章
+func GetUserNewestArticles(db *gorm.DB, userId, n int) (list []RecommendArticleVO, err error) {
+	result := db.Model(&Article{}).
+		Select("id, title, img, created_at").
+		Where("user_id = ? AND is_delete = 0 AND status = 1", userId).
+		Order("created_at DESC, id DESC").
+		Limit(n).
+		Find(&list)
+	return list, result.Error
+}
+
+// userCommentQuery 用户对外可见的评论: 已审核、未删除, 文章评论还要求文章公开
+func userCommentQuery(db *gorm.DB, userId int) *gorm.DB {
+	return db.Table("comment c").
+		Joins("LEFT JOIN article a ON c.type = 1 AND a.id = c.topic_id").
+		Where("c.user_id = ? AND c.is_review = 1 AND c.is_reject = 0 AND c.is_delete = 0", userId).
+		Where("c.type <> 1 OR (a.is_delete = 0 AND a.status = 1)")
+}
+
+// GetUserRecentComments 用户最近的 n 条对外可见的评论
+func GetUserRecentComments(db *gorm.DB, userId, n int) (list []UserCommentVO, err error) {
+	result := userCommentQuery(db, userId).
+		Select("c.id, c.type, c.topic_id, c.content_html, c.created_at, a.title AS article_title").
+		Order("c.id DESC").
+		Limit(n).
+		Find(&list)
+	return list, result.Error
+}
+
+// GetUserStats 用户的评论数和评论获得的点赞数; 文章数和文章的点赞数 (保存在 Redis 中) 由调用方填充
+func GetUserStats(db *gorm.DB, userId int) (*UserStats, error) {
+	var stats UserStats
+	var comment struct {
+		Count int64
+		Likes int64
+	}
+	result := userCommentQuery(db, userId).
+		Select("COUNT(*) AS count, COALESCE(SUM(c.like_count), 0) AS likes").
+		Take(&comment)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	stats.CommentCount, stats.LikeCount = comment.Count, comment.Likes
+	return &stats, nil
+}
+
+// GetAuthorList 发表过公开文章的用户, 文章多的在前
+func GetAuthorList(db *gorm.DB) (list []AuthorVO, err error) {
+	result := profileQuery(db).
+		Select("ua.id, ua.created_at, ui.nickname, ui.avatar, ui.intro, ui.website, ui.is_public, COUNT(a.id) AS article_count").
+		Joins("JOIN article a ON a.user_id = ua.id AND a.is_delete = 0 AND a.status = 1").
+		Group("ua.id, ua.created_at, ui.nickname, ui.avatar, ui.intro, ui.website, ui.is_public").
+		Order("article_count DESC, ua.id").
+		Find(&list)
+	return list, result.Error
+}
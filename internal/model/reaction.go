@@ -0,0 +1,39 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 表情回应的对象类型
+const (
+	REACTION_ARTICLE = "article"
+	REACTION_COMMENT = "comment"
+)
+
+// Reaction 用户对文章/评论的表情回应, 同一个用户对同一个对象可以回应多个不同的表情
+// 计数和用户的回应集合保存在 Redis 中 (通过 Lua 脚本原子切换), 数据库中持久化每一条回应
+type Reaction struct {
+	Model
+	TargetType string `gorm:"type:varchar(20);uniqueIndex:idx_reaction;comment:对象类型(article/comment)" json:"target_type"`
+	TargetId   int    `gorm:"uniqueIndex:idx_reaction;comment:对象id" json:"target_id"`
+	UserId     int    `gorm:"uniqueIndex:idx_reaction" json:"user_id"`
+	Emoji      string `gorm:"type:varchar(32);uniqueIndex:idx_reaction" json:"emoji"`
+}
+
+// SaveReaction 持久化表情回应: add 为 true 时新增 (已存在则忽略), 否则删除
+func SaveReaction(db *gorm.DB, targetType string, targetId, userId int, emoji string, add bool) error {
+	reaction := Reaction{TargetType: targetType, TargetId: targetId, UserId: userId, Emoji: emoji}
+	if add {
+		return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction).Error
+	}
+	result := db.Where("target_type = ? AND target_id = ? AND user_id = ? AND emoji = ?", targetType, targetId, userId, emoji).
+		Delete(&Reaction{})
+	return result.Error
+}
+
+// GetReactions 获取某一类对象的全部表情回应, 用于重建 Redis 中的数据
+func GetReactions(db *gorm.DB, targetType string) (list []Reaction, err error) {
+	result := db.Where("target_type = ?", targetType).Find(&list)
+	return list, result.Error
+}
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 举报原因分类
+const (
+	REPORT_SPAM    = "spam"    // 垃圾广告
+	REPORT_ABUSE   = "abuse"   // 辱骂攻击
+	REPORT_PORN    = "porn"    // 色情低俗
+	REPORT_ILLEGAL = "illegal" // 违法违规
+	REPORT_OTHER   = "other"   // 其他
+)
+
+// Report 读者对评论/留言的举报, 每个用户对同一内容只能举报一次
+// 举报对象类型与审核队列一致 (MODERATION_COMMENT / MODERATION_MESSAGE)
+type Report struct {
+	Model
+	TargetType string `gorm:"type:varchar(20);uniqueIndex:idx_report;comment:举报对象类型" json:"target_type"`
+	TargetId   int    `gorm:"uniqueIndex:idx_report;comment:举报对象id" json:"target_id"`
+	UserId     int    `gorm:"uniqueIndex:idx_report;comment:举报人" json:"user_id"`
+	Reason     string `gorm:"type:varchar(20);comment:举报原因分类" json:"reason"`
+	Detail     string `gorm:"type:varchar(255);comment:补充说明" json:"detail"`
+	IsHandled  bool   `gorm:"comment:是否已处理" json:"is_handled"`
+}
+
+// ReportSummary 按举报对象汇总的举报
+type ReportSummary struct {
+	TargetType string         `json:"target_type"`
+	TargetId   int            `json:"target_id"`
+	Count      int            `json:"count"`                   // 举报次数
+	Reasons    map[string]int `json:"reasons" gorm:"-"`        // 各原因的举报次数
+	Nickname   string         `json:"nickname" gorm:"-"`       // 被举报内容的作者
+	Content    string         `json:"content" gorm:"-"`        // 被举报的内容, 已删除时为空
+	IsReview   bool           `json:"is_review" gorm:"-"`      // 是否仍对外展示
+	LastId     int            `json:"-" gorm:"column:last_id"` // 最后一次举报的 id, 用于排序
+}
+
+// HasReported 用户是否已经举报过该内容
+func HasReported(db *gorm.DB, targetType string, targetId, userId int) (bool, error) {
+	var count int64
+	result := db.Model(&Report{}).
+		Where("target_type = ? AND target_id = ? AND user_id = ?", targetType, targetId, userId).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// AddReport 新增举报, 返回该内容未处理的举报次数
+// 同一用户已经举报过该内容时 (包括并发的重复请求, 由唯一索引保证) 不新增, created 为 false
+func AddReport(db *gorm.DB, report *Report) (created bool, count int64, err error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, 0, result.Error
+	}
+	result = db.Model(&Report{}).
+		Where("target_type = ? AND target_id = ? AND is_handled = ?", report.TargetType, report.TargetId, false).
+		Count(&count)
+	return true, count, result.Error
+}
+
+// HideReportedTarget 举报次数达到阈值时隐藏内容 (转为待审核), 等待审核人员处理
+func HideReportedTarget(db *gorm.DB, targetType string, targetId int) error {
+	var table any = &Comment{}
+	if targetType == MODERATION_MESSAGE {
+		table = &Message{}
+	}
+	result := db.Model(table).Where("id = ?", targetId).Update("is_review", false)
+	return result.Error
+}
+
+// GetReportSummaryList 按举报对象汇总的举报列表, 按举报次数排序
+func GetReportSummaryList(db *gorm.DB, page, size int, targetType string, isHandled bool) (list []ReportSummary, total int64, err error) {
+	query := db.Model(&Report{}).
+		Select("target_type, target_id, COUNT(*) AS count, MAX(id) AS last_id").
+		Where("is_handled = ?", isHandled).
+		Group("target_type, target_id")
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	if err := db.Table("(?) AS r", query).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("count DESC, last_id DESC").Scopes(Paginate(page, size)).Find(&list)
+	if result.Error != nil || len(list) == 0 {
+		return list, total, result.Error
+	}
+
+	type key struct {
+		typ string
+		id  int
+	}
+	index := make(map[key]int, len(list))
+	ids := make(map[string][]int)
+	for i, v := range list {
+		index[key{v.TargetType, v.TargetId}] = i
+		ids[v.TargetType] = append(ids[v.TargetType], v.TargetId)
+		list[i].Reasons = make(map[string]int)
+	}
+
+	for typ, typIds := range ids {
+		// 各原因的举报次数
+		var reasons []struct {
+			TargetId int
+			Reason   string
+			Count    int
+		}
+		result := db.Model(&Report{}).
+			Select("target_id, reason, COUNT(*) AS count").
+			Where("is_handled = ? AND target_type = ? AND target_id IN ?", isHandled, typ, typIds).
+			Group("target_id, reason").
+			Find(&reasons)
+		if result.Error != nil {
+			return nil, 0, result.Error
+		}
+		for _, r := range reasons {
+			list[index[key{typ, r.TargetId}]].Reasons[r.Reason] = r.Count
+		}
+
+		// 被举报的内容, 以及是否仍对外展示
+		targets, err := GetModerationTargets(db, typ, typIds)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, t := range targets {
+			item := &list[index[key{typ, t.ID}]]
+			item.Nickname, item.Content = t.Nickname, moderationContent(typ, t.Content)
+		}
+		var visible []int // 举报对象类型 (comment / message) 即表名
+		if err := db.Table(typ).Where("id IN ? AND is_review = ?", typIds, true).Pluck("id", &visible).Error; err != nil {
+			return nil, 0, err
+		}
+		for _, id := range visible {
+			list[index[key{typ, id}]].IsReview = true
+		}
+	}
+	return list, total, nil
+}
+
+// GetReportList 某个内容的举报明细
+func GetReportList(db *gorm.DB, targetType string, targetId int) (list []Report, err error) {
+	result := db.Where("target_type = ? AND target_id = ?", targetType, targetId).Order("id DESC").Find(&list)
+	return list, result.Error
+}
+
+// HandleReports 将内容的举报标记为已处理
+func HandleReports(db *gorm.DB, targetType string, targetIds []int) (int64, error) {
+	result := db.Model(&Report{}).
+		Where("target_type = ? AND target_id IN ? AND is_handled = ?", targetType, targetIds, false).
+		Update("is_handled", true)
+	return result.RowsAffected, result.Error
+}
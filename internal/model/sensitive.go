@@ -0,0 +1,68 @@
+package model
+
+import (
+	"gin-blog-server/internal/utils/sensitive"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// SensitiveList 敏感词词库, 同一词库中的敏感词使用相同的处理方式
+type SensitiveList struct {
+	Model
+	Name      string `gorm:"type:varchar(50);not null;comment:词库名称" json:"name"`
+	Action    int    `gorm:"type:tinyint(1);not null;comment:处理方式(1.替换为* 2.转人工审核 3.直接拒绝)" json:"action"`
+	Words     string `gorm:"type:text;comment:敏感词, 每行一个" json:"words"`
+	IsDisable bool   `json:"is_disable"`
+}
+
+func GetSensitiveList(db *gorm.DB, num, size int, keyword string) (list []SensitiveList, total int64, err error) {
+	db = db.Model(&SensitiveList{})
+	if keyword != "" {
+		db = db.Where("name LIKE ?", "%"+keyword+"%")
+	}
+	db = db.Count(&total)
+	result := db.Order("id DESC").Scopes(Paginate(num, size)).Find(&list)
+	return list, total, result.Error
+}
+
+func SaveOrUpdateSensitiveList(db *gorm.DB, id int, name string, action int, words string, isDisable bool) (*SensitiveList, error) {
+	list := SensitiveList{
+		Model:     Model{ID: id},
+		Name:      name,
+		Action:    action,
+		Words:     words,
+		IsDisable: isDisable,
+	}
+
+	var result *gorm.DB
+	if id > 0 {
+		result = db.Select("name", "action", "words", "is_disable").Updates(&list)
+	} else {
+		result = db.Create(&list)
+	}
+	return &list, result.Error
+}
+
+func DeleteSensitiveLists(db *gorm.DB, ids []int) (int64, error) {
+	result := db.Where("id in ?", ids).Delete(&SensitiveList{})
+	return result.RowsAffected, result.Error
+}
+
+// GetSensitiveWords 获取所有启用词库中的敏感词, 用于构建敏感词匹配器
+func GetSensitiveWords(db *gorm.DB) ([]sensitive.Word, error) {
+	var lists []SensitiveList
+	result := db.Where("is_disable = ?", false).Find(&lists)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	words := make([]sensitive.Word, 0)
+	for _, list := range lists {
+		for _, word := range strings.Split(list.Words, "\n") {
+			if word = strings.TrimSpace(word); word != "" {
+				words = append(words, sensitive.Word{Word: word, Action: sensitive.Action(list.Action)})
+			}
+		}
+	}
+	return words, nil
+}
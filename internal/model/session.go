@@ -0,0 +1,61 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+/*
+登录会话:
+  - 每次登录 (同一个令牌族) 记录一个会话: 设备、登录 IP、登录时间和最近活跃时间
+  - 吊销会话即吊销对应的令牌族 (见 revokeTokens), 访问令牌同时加入吊销列表, 之后的请求立即被拒绝
+*/
+
+// UserSession 用户登录会话
+type UserSession struct {
+	Model
+	UserId     int        `gorm:"index;comment:用户id" json:"user_id"`
+	FamilyId   string     `gorm:"type:varchar(64);uniqueIndex;comment:令牌族" json:"-"`
+	Browser    string     `gorm:"type:varchar(50);comment:浏览器" json:"browser"`
+	OS         string     `gorm:"type:varchar(50);comment:操作系统" json:"os"`
+	IpAddress  string     `gorm:"type:varchar(50);comment:登录IP" json:"ip_address"`
+	IpSource   string     `gorm:"type:varchar(100);comment:登录地址" json:"ip_source"`
+	LastSeenAt time.Time  `gorm:"comment:最近活跃时间" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"comment:过期时间 (刷新令牌过期时间)" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"comment:吊销时间" json:"revoked_at"`
+}
+
+// CreateUserSession 记录登录会话
+func CreateUserSession(db *gorm.DB, session *UserSession) error {
+	return db.Create(session).Error
+}
+
+// GetUserSession 根据 id 获取会话
+func GetUserSession(db *gorm.DB, id int) (*UserSession, error) {
+	var session UserSession
+	result := db.First(&session, id)
+	return &session, result.Error
+}
+
+// GetUserSessions 获取用户未吊销且未过期的会话, 最近活跃的在前
+func GetUserSessions(db *gorm.DB, userId int) (list []UserSession, err error) {
+	result := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&list)
+	return list, result.Error
+}
+
+// RefreshUserSession 刷新令牌后延长会话的有效期
+func RefreshUserSession(db *gorm.DB, familyId string, expiresAt time.Time) error {
+	return db.Model(&UserSession{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Updates(map[string]any{"last_seen_at": time.Now(), "expires_at": expiresAt}).Error
+}
+
+// TouchUserSession 根据访问令牌 id 更新会话的最近活跃时间
+func TouchUserSession(db *gorm.DB, jti string) error {
+	family := db.Model(&RefreshToken{}).Select("family_id").Where("access_jti = ?", jti)
+	return db.Model(&UserSession{}).
+		Where("family_id IN (?) AND revoked_at IS NULL", family).
+		Update("last_seen_at", time.Now()).Error
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+/*
+刷新令牌:
+  - 登录时签发一个短期的访问令牌 (JWT) 和一个长期的刷新令牌, 数据库只保存刷新令牌的 SHA-256 摘要
+  - 同一次登录后续刷新得到的令牌属于同一个令牌族 (family), 每次刷新都会轮换: 旧的刷新令牌标记为已使用, 签发新的刷新令牌
+  - 已使用的刷新令牌再次出现, 说明令牌可能已被盗用, 吊销整个令牌族 (包括其签发的访问令牌)
+*/
+
+// RefreshToken 刷新令牌
+type RefreshToken struct {
+	Model
+	UserId    int        `gorm:"index;comment:用户id" json:"user_id"`
+	FamilyId  string     `gorm:"type:varchar(64);index;comment:令牌族" json:"family_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;comment:刷新令牌摘要" json:"-"`
+	AccessJti string     `gorm:"type:varchar(64);index;comment:同时签发的访问令牌id" json:"-"`
+	ExpiresAt time.Time  `gorm:"comment:过期时间" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"comment:使用(轮换)时间" json:"used_at"`
+	RevokedAt *time.Time `gorm:"comment:吊销时间" json:"revoked_at"`
+}
+
+// CreateRefreshToken 保存刷新令牌
+func CreateRefreshToken(db *gorm.DB, token *RefreshToken) error {
+	return db.Create(token).Error
+}
+
+// GetRefreshTokenByHash 根据摘要获取刷新令牌
+func GetRefreshTokenByHash(db *gorm.DB, hash string) (*RefreshToken, error) {
+	var token RefreshToken
+	result := db.Where("token_hash = ?", hash).First(&token)
+	return &token, result.Error
+}
+
+// GetRefreshTokenByJti 根据同时签发的访问令牌 id 获取刷新令牌
+func GetRefreshTokenByJti(db *gorm.DB, jti string) (*RefreshToken, error) {
+	var token RefreshToken
+	result := db.Where("access_jti = ?", jti).First(&token)
+	return &token, result.Error
+}
+
+// UseRefreshToken 将刷新令牌标记为已使用, 返回 false 表示令牌已经被使用或吊销 (并发刷新时只有一个请求成功)
+func UseRefreshToken(db *gorm.DB, id int) (bool, error) {
+	result := db.Model(&RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeTokenFamily 吊销整个令牌族, 返回该族签发过的访问令牌 id
+func RevokeTokenFamily(db *gorm.DB, familyId string) ([]string, error) {
+	return revokeTokens(db, "family_id = ?", familyId)
+}
+
+// RevokeUserTokens 吊销用户的全部令牌 (强制下线), 返回签发过的访问令牌 id
+func RevokeUserTokens(db *gorm.DB, userId int) ([]string, error) {
+	return revokeTokens(db, "user_id = ?", userId)
+}
+
+// revokeTokens 吊销未过期的令牌以及对应的登录会话, 已过期的令牌签发的访问令牌也早已过期, 无需处理
+// query 只能使用刷新令牌和登录会话共有的字段 (user_id, family_id)
+func revokeTokens(db *gorm.DB, query string, args ...any) (jtis []string, err error) {
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&RefreshToken{}).Where(query, args...).Where("expires_at > ?", now).Pluck("access_jti", &jtis)
+		if result.Error != nil {
+			return result.Error
+		}
+		err := tx.Model(&RefreshToken{}).Where(query, args...).
+			Where("expires_at > ? AND revoked_at IS NULL", now).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&UserSession{}).Where(query, args...).
+			Where("revoked_at IS NULL").
+			Update("revoked_at", now).Error
+	})
+	return jtis, err
+}
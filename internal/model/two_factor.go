@@ -0,0 +1,154 @@
+package model
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+/*
+两步验证 (TOTP):
+  - 用户先生成密钥 (未开启状态), 使用验证器扫码后提交一次验证码才正式开启
+  - 开启后登录需要在密码验证通过后再提交验证码或恢复码
+  - 记录最近一次使用的时间步 (last_counter), 同一个验证码不能重复使用
+  - 恢复码只保存 SHA-256 摘要, 每个只能使用一次, 重新生成时旧的恢复码全部作废
+  - 角色可设置 require_2fa, 拥有该角色的用户必须开启两步验证才能访问后台接口
+*/
+
+// TwoFactor 用户的两步验证设置
+type TwoFactor struct {
+	Model
+	UserId      int        `gorm:"uniqueIndex;comment:用户id" json:"user_id"`
+	Secret      string     `gorm:"type:varchar(64);comment:TOTP密钥" json:"-"`
+	IsEnabled   bool       `gorm:"comment:是否已开启" json:"is_enabled"`
+	LastCounter int64      `gorm:"comment:最近一次使用的时间步" json:"-"`
+	EnabledAt   *time.Time `gorm:"comment:开启时间" json:"enabled_at"`
+}
+
+// RecoveryCode 两步验证恢复码
+type RecoveryCode struct {
+	Model
+	UserId   int        `gorm:"index;comment:用户id" json:"user_id"`
+	CodeHash string     `gorm:"type:varchar(64);index;comment:恢复码摘要" json:"-"`
+	UsedAt   *time.Time `gorm:"comment:使用时间" json:"used_at"`
+}
+
+// RequireTwoFactor 用户拥有的 (未禁用的) 角色中是否有要求开启两步验证的, 需要预加载 Roles
+func (u *UserAuth) RequireTwoFactor() bool {
+	for _, role := range u.Roles {
+		if role.Require2FA && !role.IsDisable {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTwoFactorRequired 用户拥有的 (未禁用的) 角色中是否有要求开启两步验证的, 用于未预加载 Roles 的场景
+func IsTwoFactorRequired(db *gorm.DB, userId int) (bool, error) {
+	var count int64
+	result := db.Model(&Role{}).
+		Joins("JOIN user_auth_role ON user_auth_role.role_id = role.id").
+		Where("user_auth_role.user_auth_id = ? AND role.require_2fa = ? AND role.is_disable = ?", userId, true, false).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// GetTwoFactor 获取用户的两步验证设置, 没有时返回 nil
+func GetTwoFactor(db *gorm.DB, userId int) (*TwoFactor, error) {
+	var tf TwoFactor
+	result := db.Where("user_id = ?", userId).First(&tf)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &tf, result.Error
+}
+
+// IsTwoFactorEnabled 用户是否已开启两步验证
+func IsTwoFactorEnabled(db *gorm.DB, userId int) (bool, error) {
+	var count int64
+	result := db.Model(&TwoFactor{}).Where("user_id = ? AND is_enabled = ?", userId, true).Count(&count)
+	return count > 0, result.Error
+}
+
+// SaveTwoFactorSecret 保存新生成的密钥 (未开启状态), 覆盖之前未开启的密钥
+func SaveTwoFactorSecret(db *gorm.DB, userId int, secret string) error {
+	tf, err := GetTwoFactor(db, userId)
+	if err != nil {
+		return err
+	}
+	if tf == nil {
+		return db.Create(&TwoFactor{UserId: userId, Secret: secret}).Error
+	}
+	return db.Model(tf).Select("secret", "is_enabled", "last_counter", "enabled_at").
+		Updates(TwoFactor{Secret: secret}).Error
+}
+
+// EnableTwoFactor 开启两步验证, 同时保存恢复码摘要
+func EnableTwoFactor(db *gorm.DB, userId int, counter int64, codeHashes []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&TwoFactor{}).Where("user_id = ?", userId).
+			Updates(map[string]any{"is_enabled": true, "last_counter": counter, "enabled_at": &now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userId, codeHashes)
+	})
+}
+
+// DisableTwoFactor 关闭两步验证, 删除密钥和恢复码
+func DisableTwoFactor(db *gorm.DB, userId int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&TwoFactor{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error
+	})
+}
+
+// UseTwoFactorCounter 记录已使用的时间步, 返回 false 表示该时间步 (或更晚的) 已经使用过, 防止验证码重放
+func UseTwoFactorCounter(db *gorm.DB, userId int, counter int64) (bool, error) {
+	result := db.Model(&TwoFactor{}).
+		Where("user_id = ? AND is_enabled = ? AND last_counter < ?", userId, true, counter).
+		Update("last_counter", counter)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReplaceRecoveryCodes 重新生成恢复码, 旧的恢复码全部作废
+func ReplaceRecoveryCodes(db *gorm.DB, userId int, codeHashes []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userId, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userId int, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userId).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, RecoveryCode{UserId: userId, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode 使用恢复码, 返回 false 表示恢复码不存在或已使用
+func UseRecoveryCode(db *gorm.DB, userId int, codeHash string) (bool, error) {
+	result := db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountRecoveryCodes 剩余可用的恢复码数量
+func CountRecoveryCodes(db *gorm.DB, userId int) (int64, error) {
+	var count int64
+	result := db.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userId).Count(&count)
+	return count, result.Error
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"errors"
+	"gin-blog-server/internal/utils"
 	"gorm.io/gorm"
 	"time"
 )
@@ -14,13 +15,20 @@ type UserInfo struct {
 	Avatar   string `json:"avatar" gorm:"type:varchar(1024);not null"`        // 头像最大长度1024字符，保存用户的头像
 	Intro    string `json:"intro" gorm:"type:varchar(255)"`                   // 简介最大长度255字符，保存用户的简介
 	Website  string `json:"website" gorm:"type:varchar(255)"`                 // 网站最大长度255字符，保存用户的网站等链接
+
+	NotifyReply   bool `json:"notify_reply" gorm:"default:true;comment:评论被回复时邮件通知"`
+	NotifyComment bool `json:"notify_comment" gorm:"default:true;comment:文章有新评论时邮件通知"`
+	NotifyMention bool `json:"notify_mention" gorm:"default:true;comment:被@时邮件通知"`
+
+	IsPublic bool `json:"is_public" gorm:"default:false;comment:是否公开个人主页"` // 默认不公开, 需用户主动开启; 不公开时个人主页只展示昵称、头像和注册时间
 }
 
 // UserInfoVO 返回前端的用户信息
 type UserInfoVO struct {
 	UserInfo
-	ArticleLikeSet []string `json:"article_like_set"` // 文章点赞集合
-	CommentLikeSet []string `json:"comment_like_set"` // 评论点赞集合
+	ArticleLikeSet []string   `json:"article_like_set"` // 文章点赞集合
+	CommentLikeSet []string   `json:"comment_like_set"` // 评论点赞集合
+	DeleteAt       *time.Time `json:"delete_at"`        // 申请注销后的删除时间, 未申请时为空
 }
 
 // GetUserInfoById 根据id获取用户信息
@@ -88,6 +96,35 @@ func UpdateUserInfo(db *gorm.DB, id int, nickname, avatar, intro, website string
 	return result.Error
 }
 
+// UpdateUserNotify 修改用户的邮件通知设置
+func UpdateUserNotify(db *gorm.DB, id int, notifyReply, notifyComment, notifyMention bool) error {
+	result := db.Model(&UserInfo{Model: Model{ID: id}}).
+		Select("notify_reply", "notify_comment", "notify_mention").
+		Updates(UserInfo{NotifyReply: notifyReply, NotifyComment: notifyComment, NotifyMention: notifyMention})
+	return result.Error
+}
+
+// UpdateUserPrivacy 修改用户是否公开个人主页
+func UpdateUserPrivacy(db *gorm.DB, id int, isPublic bool) error {
+	result := db.Model(&UserInfo{Model: Model{ID: id}}).Update("is_public", isPublic)
+	return result.Error
+}
+
+// UnsubscribeUserNotify 退订某一类邮件通知 (user_auth_id)
+func UnsubscribeUserNotify(db *gorm.DB, userAuthId int, kind string) error {
+	column := "notify_reply"
+	switch kind {
+	case utils.NOTIFY_COMMENT:
+		column = "notify_comment"
+	case utils.NOTIFY_MENTION:
+		column = "notify_mention"
+	}
+	result := db.Model(&UserInfo{}).
+		Where("id = (?)", db.Model(&UserAuth{}).Select("user_info_id").Where("id = ?", userAuthId)).
+		Update(column, false)
+	return result.Error
+}
+
 // GetUserList 获取用户列表
 func GetUserList(db *gorm.DB, page, size int, loginType int8, nickname, username string) (list []UserAuth, total int64, err error) {
 	if loginType != 0 {
@@ -155,6 +192,55 @@ func UpdateUserDisable(db *gorm.DB, id int, isDisable bool) error {
 	return result.Error
 }
 
+// GetUserAuthByEmail 根据邮箱 (注册邮箱即用户名, 或用户信息中的邮箱) 精确查询用户认证信息
+func GetUserAuthByEmail(db *gorm.DB, email string) (*UserAuth, error) {
+	var userAuth UserAuth
+	result := db.Model(&userAuth).
+		Joins("LEFT JOIN user_info ON user_info.id = user_auth.user_info_id").
+		Where("user_auth.username = ? OR user_info.email = ?", email, email).
+		Order("user_auth.id").
+		First(&userAuth)
+	return &userAuth, result.Error
+}
+
+// ExistUserEmail 邮箱是否已被其他账号使用 (注册邮箱即用户名, 或用户信息中的邮箱)
+func ExistUserEmail(db *gorm.DB, email string, excludeUserAuthId int) (bool, error) {
+	var count int64
+	result := db.Model(&UserAuth{}).
+		Joins("LEFT JOIN user_info ON user_info.id = user_auth.user_info_id").
+		Where("(user_auth.username = ? OR user_info.email = ?) AND user_auth.id <> ?", email, email, excludeUserAuthId).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// UpdateUserEmail 修改邮箱: 在同一个事务中修改用户名 (登录邮箱) 和用户信息中的邮箱
+// 返回 false 表示邮箱已被其他账号使用, 没有修改; 并发修改为同一邮箱时由用户名的唯一索引保证只有一个成功
+func UpdateUserEmail(db *gorm.DB, userAuthId int, email string) (bool, error) {
+	updated := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		exist, err := ExistUserEmail(tx, email, userAuthId)
+		if err != nil || exist {
+			return err
+		}
+		var auth UserAuth
+		if err := tx.First(&auth, userAuthId).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&UserAuth{}).Where("id = ?", userAuthId).Update("username", email).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&UserInfo{}).Where("id = ?", auth.UserInfoId).Update("email", email).Error; err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil && isDuplicateKey(db, err) {
+		return false, nil
+	}
+	return updated, err
+}
+
 // UpdateUserPassword 更新用户密码
 func UpdateUserPassword(db *gorm.DB, id int, password string) error {
 	userAuth := UserAuth{
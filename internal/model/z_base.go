@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"gorm.io/gorm"
 	"time"
 )
@@ -19,17 +20,31 @@ func MakeMigrate(db *gorm.DB) error {
 	db.SetupJoinTable(&Role{}, "Menus", &RoleMenu{})
 	db.SetupJoinTable(&Role{}, "Resources", &RoleResource{})
 	db.SetupJoinTable(&Role{}, "Users", &UserAuthRole{})
-	return db.AutoMigrate(
-		&Article{},      // 文章
-		&Category{},     // 分类
-		&Tag{},          // 标签
-		&Comment{},      // 评论
-		&Message{},      // 消息
-		&FriendLink{},   // 友链
-		&Page{},         // 页面
-		&Config{},       // 网站设置
-		&OperationLog{}, // 操作日志
-		&UserInfo{},     // 用户信息
+	err := db.AutoMigrate(
+		&Article{},         // 文章
+		&Category{},        // 分类
+		&Tag{},             // 标签
+		&Comment{},         // 评论
+		&CommentRevision{}, // 评论编辑历史
+		&Message{},         // 消息
+		&FriendLink{},      // 友链
+		&Page{},            // 页面
+		&Config{},          // 网站设置
+		&OperationLog{},    // 操作日志
+		&Media{},           // 上传文件
+		&SensitiveList{},   // 敏感词词库
+		&Mention{},         // @提及
+		&Notification{},    // 站内通知
+		&Reaction{},        // 表情回应
+		&ModerationLog{},   // 审核记录
+		&Report{},          // 举报
+		&Ban{},             // 封禁
+		&RefreshToken{},    // 刷新令牌
+		&TwoFactor{},       // 两步验证
+		&RecoveryCode{},    // 两步验证恢复码
+		&OAuthAccount{},    // 第三方账号
+		&UserSession{},     // 登录会话
+		&UserInfo{},        // 用户信息
 
 		&UserAuth{},     // 用户验证
 		&Role{},         // 角色
@@ -37,6 +52,15 @@ func MakeMigrate(db *gorm.DB) error {
 		&Resource{},     // 资源（接口）
 		&UserAuthRole{}, // 用户-角色 关联
 	)
+	if err != nil {
+		return err
+	}
+	// 补全历史评论的物化路径
+	if err := RebuildCommentPaths(db); err != nil {
+		return err
+	}
+	// 补全历史评论渲染后的 HTML
+	return BackfillCommentHTML(db)
 }
 
 type Model struct {
@@ -49,6 +73,14 @@ type OptionVO struct {
 	Name string `json:"name"`
 }
 
+// isDuplicateKey 是否为违反唯一索引的错误, 由数据库驱动将各自的错误码转换为 gorm.ErrDuplicatedKey
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
 // Paginate 分页函数
 func Paginate(page, size int) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
@@ -14,4 +14,10 @@ INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`)
 INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (13, '2023-12-27 22:40:22.813', '2025-08-22 23:01:35.013', 'article_cover', 'https://piccn.ihuaben.com/pic/community/202402/2221/1708610323583-s4yo4MGN16_1280-720.jpeg', '默认文章封面');
 INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (14, '2023-12-27 22:40:22.813', '2025-08-22 23:01:35.039', 'is_comment_review', 'true', '评论默认审核');
 INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (15, '2023-12-27 22:40:22.813', '2025-08-22 23:01:35.017', 'is_message_review', 'true', '留言默认审核');
-INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (16, '2023-12-27 22:59:20.110', '2025-08-22 23:01:35.035', 'about', '```javascript\nconsole.log(\"Hello World\")\n```\n\n搞搞新意思！', '');
\ No newline at end of file
+INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (16, '2023-12-27 22:59:20.110', '2025-08-22 23:01:35.035', 'about', '```javascript\nconsole.log(\"Hello World\")\n```\n\n搞搞新意思！', '');
+INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (17, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 'comment_edit_time', '10', '评论可编辑时间(分钟)');
+INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (18, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 'is_guest_comment', 'false', '允许游客评论与留言');
+INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (19, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 'max_mentions', '5', '单条评论/留言最多@的用户数');
+INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (20, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 'reaction_emojis', '👍,❤️,😄,🎉,😕,👀', '可用的表情回应(逗号分隔)');
+INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (21, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 'report_threshold', '3', '被举报多少次后自动隐藏(0不隐藏)');
+INSERT INTO `config` (`id`, `created_at`, `updated_at`, `key`, `value`, `desc`) VALUES (22, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 'danmaku_max_conn', '5', '同一IP的实时弹幕最大连接数(0不限制)');
\ No newline at end of file
@@ -69,4 +69,25 @@ INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `m
 INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (106, '2022-12-16 11:53:57.989', '2022-12-16 11:53:57.989', 0, '', '', '文件模块', 0);
 INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (107, '2022-12-16 11:54:20.891', '2022-12-16 11:54:20.891', 106, '/upload', 'POST', '文件上传', 0);
 INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (108, '2022-12-18 01:34:47.800', '2022-12-18 01:34:47.800', 3, '/article/export', 'POST', '导出文章', 0);
-INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (109, '2022-12-18 01:34:59.255', '2022-12-18 01:34:59.255', 3, '/article/import', 'POST', '导入文章', 0);
\ No newline at end of file
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (109, '2022-12-18 01:34:59.255', '2022-12-18 01:34:59.255', 3, '/article/import', 'POST', '导入文章', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (110, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 106, '/upload/sign', 'GET', '私有文件签名链接', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (111, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 106, '/upload', 'DELETE', '删除文件', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (112, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 0, '', '', '敏感词模块', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (113, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 112, '/sensitive/list', 'GET', '敏感词词库列表', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (114, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 112, '/sensitive', 'POST', '新增/编辑敏感词词库', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (115, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 112, '/sensitive', 'DELETE', '删除敏感词词库', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (116, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 112, '/sensitive/reload', 'POST', '重新加载敏感词词库', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (117, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 0, '', '', '审核模块', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (118, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 117, '/moderation/list', 'GET', '审核队列', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (119, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 117, '/moderation', 'PUT', '批量审核', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (120, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 117, '/moderation/log', 'GET', '审核记录', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (121, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 0, '', '', '举报模块', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (122, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 121, '/report/list', 'GET', '举报列表', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (123, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 121, '/report/detail', 'GET', '举报明细', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (124, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 121, '/report/dismiss', 'PUT', '驳回举报', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (125, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 0, '', '', '封禁模块', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (126, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 125, '/ban/list', 'GET', '封禁列表', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (127, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 125, '/ban', 'POST', '新增封禁', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (128, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 125, '/ban/revoke', 'PUT', '解除封禁', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (129, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 74, '/user/session/list', 'GET', '用户登录会话列表', 0);
+INSERT INTO `resource` (`id`, `created_at`, `updated_at`, `parent_id`, `url`, `method`, `name`, `anonymous`) VALUES (130, '2026-10-18 10:00:00.000', '2026-10-18 10:00:00.000', 74, '/user/session/:id', 'DELETE', '下线用户登录会话', 0);
\ No newline at end of file
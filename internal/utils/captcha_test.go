@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMathCaptcha(t *testing.T) {
+	for _, level := range []int{0, CAPTCHA_EASY, CAPTCHA_MEDIUM, CAPTCHA_HARD} {
+		for range 100 {
+			question, answer := NewMathCaptcha(level)
+			assert.True(t, strings.HasSuffix(question, "=?"))
+			expr := strings.TrimSuffix(question, "=?")
+			i := strings.IndexAny(expr, "+-×")
+			assert.Greater(t, i, 0)
+			a, err := strconv.Atoi(expr[:i])
+			assert.Nil(t, err)
+			op, size := utf8.DecodeRuneInString(expr[i:])
+			b, err := strconv.Atoi(expr[i+size:])
+			assert.Nil(t, err)
+
+			n, err := strconv.Atoi(answer)
+			assert.Nil(t, err)
+			switch op {
+			case '+':
+				assert.Equal(t, a+b, n)
+			case '-':
+				assert.Equal(t, a-b, n)
+				assert.GreaterOrEqual(t, n, 0)
+			default:
+				assert.Equal(t, CAPTCHA_HARD, level)
+				assert.Equal(t, a*b, n)
+			}
+			if level == CAPTCHA_EASY {
+				assert.Less(t, a, 10)
+				assert.Less(t, b, 10)
+			}
+		}
+	}
+}
+
+func TestCaptchaSVG(t *testing.T) {
+	svg := CaptchaSVG("7+5=?")
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	// 题目不能以文本形式出现在图片中
+	assert.NotContains(t, svg, "<text")
+	assert.NotContains(t, svg, "7+5")
+}
+
+func TestGravatar(t *testing.T) {
+	assert.Equal(t, Gravatar(" Foo@Example.com "), Gravatar("foo@example.com"))
+	assert.Equal(t, "https://www.gravatar.com/avatar/"+MD5("foo@example.com")+"?d=identicon", Gravatar("foo@example.com"))
+}
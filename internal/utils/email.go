@@ -3,122 +3,65 @@ package utils
 import (
 	"bytes"
 	"crypto/tls"
-	"encoding/base64"
 	"errors"
-	"fmt"
 	"gin-blog-server/internal/global"
 	"github.com/k3a/html2text"
-	"github.com/thanhpk/randstr"
 	"github.com/vanng822/go-premailer/premailer"
 	"gopkg.in/gomail.v2"
 	"html/template"
 	"io/fs"
 	"log/slog"
+	"net/url"
 	"path/filepath"
 	"strconv"
 	"strings"
 )
 
-type EmailData struct {
-	URL      template.URL //验证链接
-	UserName string       //用户名即邮箱地址
-	Subject  string       //邮箱主题
-}
-
 // Format 将邮箱地址转换成小写，并去除空格
 // 格式化邮件可以防止写错大小写重复注册，同时给用户预留犯错空间，输入空格和大小写错误也能正常处理
 func Format(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
 
-func GenEmailVerificationInfo(email, password string) string {
-	code := GetCode()
-	info := Encode(email + "|" + password + "|" + code)
-	return info
-}
-
-// Encode  返回生成 base64 编码
-func Encode(s string) string {
-	data := base64.StdEncoding.EncodeToString([]byte(s))
-	return data
-}
-
-// GetCode 生成随机字符串
-func GetCode() string {
-	code := randstr.String(24)
-	return code
+// GetEmailVerifyURL 获取注册邮箱验证链接, 基于配置中的博客对外访问地址 (Server.PublicURL)
+// 点击该链接可以触发 api/email/verify -> 进一步将账号存储到对应数据库中，完成账号注册
+func GetEmailVerifyURL(token string) string {
+	return GetPublicURL("/api/email/verify?token=" + url.QueryEscape(token))
 }
 
-// ParseEmailVerificationInfo 返回解析base64字符串后的 邮箱地址和code
-func ParseEmailVerificationInfo(info string) (string, string, error) {
-	data, err := Decode(info)
-	if err != nil {
-		return "", "", err
-	}
-	str := strings.Split(data, "|")
-	if len(str) != 3 {
-		return "", "", errors.New("wrong verification info format")
-	}
-	return str[0], str[1], nil
-}
+// 邮件模版目录
+const emailTemplateDir = "./assets/templates"
 
-// Decode 返回解码 base64
-func Decode(s string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(s)
-	if err != nil {
-		return "", errors.New("email verify failed, decode error")
-	}
-	return string(data), nil
-}
-
-// GetEmailData 生成邮件数据
-func GetEmailData(email string, info string) *EmailData {
-	return &EmailData{
-		URL:      template.URL(GetEmailVerifyURL(info)),
-		UserName: email,
-		Subject:  "邮箱验证",
-	}
-}
-
-// GetEmailVerifyURL 获取邮件验证链接
-func GetEmailVerifyURL(info string) string {
-	//baseurl := global.GetConfig().Server.Port
-	//if baseurl[0] == ':' {
-	//	baseurl = fmt.Sprintf("localhost%s", baseurl)
-	//}
-	// FIXME:如果是用docker部署,则 注释上面的代码，使用下面的代码
-	baseurl := "8.138.134.122:8765" //切记不需要加端口
-	// 点击该链接可以触发 api/email/verify -> 进一步将账号存储到对应数据库中，完成账号注册
-	return fmt.Sprintf("%s/api/email/verify?info=%s", baseurl, info)
-}
-
-// SendEmail 发送邮件
-// 发送邮件需要配置邮箱服务器信息， 可以在config.yaml中配置
-// 以下情况会发生错误: 1. 邮箱配置错误,smtp信息错误 2. 修改模板后,解析模板失败!
-func SendEmail(email string, data *EmailData) error {
+// SendTemplateEmail 使用 assets/templates 中的模版发送邮件
+// name 为模版文件名, 模版与 base.tpl、style.tpl 一起解析, 通过 define "content" 填充邮件正文
+// headers 为额外的邮件头, 例如 List-Unsubscribe
+func SendTemplateEmail(to, subject, name string, data any, headers map[string]string) error {
 	config := global.GetConfig().Email
 	from := config.From
 	Pass := config.SmtpPass
 	User := config.SmtpUser
-	to := email
 	Host := config.Host
 	Port := config.Port
 
-	slog.Info("User: " + User + "Pass " + Pass + "Host " + Host + "Port: " + strconv.Itoa(Port))
+	slog.Info("User: " + User + "Host " + Host + "Port: " + strconv.Itoa(Port))
 
 	var body bytes.Buffer
-	//解析模板
-	Template, err := ParseTemplateDir("./assets/templates")
-
+	//解析模板: 每个模版都定义了 "content", 因此需要单独解析, 不能和其他模版放在同一个模版集合中
+	Template, err := template.ParseFiles(
+		filepath.Join(emailTemplateDir, "base.tpl"),
+		filepath.Join(emailTemplateDir, "style.tpl"),
+		filepath.Join(emailTemplateDir, name),
+	)
 	if err != nil {
 		return errors.New("解析模版失败")
 	}
 	slog.Info("解析模版成功！")
 
-	fmt.Println("URL:", data.URL)
 	// 执行模版
 	// 把html数据存储在body中， 第二个参数是模板名称， 第三个参数是模板数据（把模板中的占位符换成data数据）
-	Template.ExecuteTemplate(&body, "email-verify.tpl", &data)
+	if err := Template.ExecuteTemplate(&body, name, data); err != nil {
+		return err
+	}
 
 	//为了确保html文件在各个邮件客户端都能正常显示，把html转换成内联模式
 	htmlString := body.String()
@@ -131,7 +74,10 @@ func SendEmail(email string, data *EmailData) error {
 	//设定m头
 	m.SetHeader("From", from)
 	m.SetHeader("To", to)
-	m.SetHeader("Subject", data.Subject)
+	m.SetHeader("Subject", subject)
+	for k, v := range headers {
+		m.SetHeader(k, v)
+	}
 	//设定邮件内容
 	m.SetBody("text/html", htmlline)
 	//添加纯文本样式的内容作为备选
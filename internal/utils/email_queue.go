@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-blog-server/internal/global"
+	"github.com/redis/go-redis/v9"
+	"log/slog"
+	"time"
+)
+
+/*
+邮件发送队列:
+  - 业务代码只负责把邮件任务写入 Redis 列表 (LPUSH), 立即返回, SMTP 服务器缓慢不会阻塞请求
+  - 后台 worker 通过 BRPOPLPUSH 取出任务, 同时放入处理中列表 (EMAIL_PROCESSING), 发送完成后才从处理中列表移除;
+    发送失败时重新入队, 最多尝试 emailMaxAttempts 次
+  - worker 启动时将处理中列表里的任务 (上次发送时服务退出) 放回队列, 服务重启或崩溃不会丢失未发送的邮件,
+    但发送成功后、移除前退出的邮件会再发送一次 (至少发送一次)
+*/
+
+// EmailTask 邮件发送任务
+type EmailTask struct {
+	To       string            `json:"to"`
+	Subject  string            `json:"subject"`
+	Template string            `json:"template"` // assets/templates 中的模版文件名
+	Data     map[string]string `json:"data"`     // 模版数据
+	Headers  map[string]string `json:"headers"`  // 额外的邮件头
+	Attempts int               `json:"attempts"` // 已尝试次数
+}
+
+// 单个邮件任务最多尝试次数
+const emailMaxAttempts = 3
+
+// EnqueueEmail 将邮件任务加入发送队列
+func EnqueueEmail(ctx context.Context, rdb *redis.Client, task EmailTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	return rdb.LPush(ctx, global.EMAIL_QUEUE, data).Err()
+}
+
+// RunEmailWorker 持续从队列中取出邮件任务并发送, ctx 取消时退出
+func RunEmailWorker(ctx context.Context, rdb *redis.Client) {
+	recoverEmailTasks(ctx, rdb)
+	for {
+		data, err := rdb.BRPopLPush(ctx, global.EMAIL_QUEUE, global.EMAIL_PROCESSING, 5*time.Second).Result()
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, redis.Nil) { // 超时, 队列为空
+			continue
+		}
+		if err != nil {
+			slog.Error("读取邮件队列失败", "err", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		processEmailTask(ctx, rdb, data)
+	}
+}
+
+// recoverEmailTasks 将上次退出时未发送完成的任务放回队列
+func recoverEmailTasks(ctx context.Context, rdb *redis.Client) {
+	for {
+		_, err := rdb.RPopLPush(ctx, global.EMAIL_PROCESSING, global.EMAIL_QUEUE).Result()
+		if errors.Is(err, redis.Nil) {
+			return
+		}
+		if err != nil {
+			slog.Error("恢复未发送的邮件任务失败", "err", err)
+			return
+		}
+	}
+}
+
+// processEmailTask 发送一个邮件任务, 完成 (发送成功、格式错误或达到最大尝试次数) 后从处理中列表移除
+// 发送失败时重新入队, 重新入队和移除在同一个事务中执行
+func processEmailTask(ctx context.Context, rdb *redis.Client, data string) {
+	var task EmailTask
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		slog.Error("邮件任务格式错误", "task", data, "err", err)
+		ackEmailTask(ctx, rdb, data, nil)
+		return
+	}
+
+	err := SendTemplateEmail(task.To, task.Subject, task.Template, task.Data, task.Headers)
+	if err == nil {
+		ackEmailTask(ctx, rdb, data, nil)
+		return
+	}
+	task.Attempts++
+	slog.Warn("邮件发送失败", "to", task.To, "template", task.Template, "attempts", task.Attempts, "err", err)
+	// 重新放到队尾, 先发送其他邮件
+	var retry []byte
+	if task.Attempts < emailMaxAttempts {
+		retry, _ = json.Marshal(task) // 只有字符串和整数字段, 不会失败
+	}
+	ackEmailTask(ctx, rdb, data, retry)
+}
+
+// ackEmailTask 从处理中列表移除任务, retry 不为空时同时将其重新加入队列
+func ackEmailTask(ctx context.Context, rdb *redis.Client, data string, retry []byte) {
+	pipe := rdb.TxPipeline()
+	if retry != nil {
+		pipe.LPush(ctx, global.EMAIL_QUEUE, retry)
+	}
+	pipe.LRem(ctx, global.EMAIL_PROCESSING, 1, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Error("更新邮件任务状态失败", "err", err)
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -54,3 +56,18 @@ func MD5(str string, b ...byte) string {
 	//如果有附加字节，将它们添加到哈希计算中
 	return hex.EncodeToString(h.Sum(b))
 }
+
+// RandomToken 生成 n 字节的随机令牌 (十六进制编码, 长度为 2n)
+// 使用 crypto/rand, 可用于 cookie 标识、验证码 id 等不可预测的场景
+func RandomToken(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SHA256 生成 SHA-256 哈希值 (十六进制编码)
+// 用于保存刷新令牌等高熵随机令牌的摘要, 不适用于密码 (密码使用 BcryptHash)
+func SHA256(str string) string {
+	sum := sha256.Sum256([]byte(str))
+	return hex.EncodeToString(sum[:])
+}
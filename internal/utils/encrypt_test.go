@@ -19,3 +19,13 @@ func TestEncrypt(t *testing.T) {
 func TestMD5(t *testing.T) {
 	assert.Equal(t, "e10adc3949ba59abbe56e057f20f883e", MD5("123456"))
 }
+
+func TestRandomToken(t *testing.T) {
+	token := RandomToken(16)
+	assert.Len(t, token, 32)
+	assert.NotEqual(t, token, RandomToken(16))
+}
+
+func TestSHA256(t *testing.T) {
+	assert.Equal(t, "8d969eef6ecad3c29a3a629280e686cf0c3f5d5a86aff3ca12020c923adc6c92", SHA256("123456"))
+}
@@ -0,0 +1,9 @@
+package utils
+
+import "strings"
+
+// Gravatar 根据邮箱生成 Gravatar 头像地址
+// 邮箱没有设置 Gravatar 头像时显示默认的 identicon 图案
+func Gravatar(email string) string {
+	return "https://www.gravatar.com/avatar/" + MD5(strings.ToLower(strings.TrimSpace(email))) + "?d=identicon"
+}
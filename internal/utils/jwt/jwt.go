@@ -25,18 +25,20 @@ type MyClaims struct {
 // GenToken 生成新的JWT
 // secret: 用于签名的密钥（通常是一个私钥或者密钥
 // issuer: 签发者
-// expireHour: Token 过期的小时数
+// jti: Token 唯一标识, 用于服务端吊销 Token
+// expire: Token 有效时长
 // userId: 用户id
 // roleIds: 角用户的角色ID数组
-func GenToken(secret, issuer string, expireHour, userId int, roleIds []int) (string, error) {
+func GenToken(secret, issuer, jti string, expire time.Duration, userId int, roleIds []int) (string, error) {
 	//创建一个 MyClaims 实例，填充JWT的Claims数据
 	claims := MyClaims{
 		UserId:  userId,  // 用户id
 		RoleIds: roleIds, // 角色id列表
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expireHour))), // 过期时间
-			Issuer:    issuer,                                                                    // 签发者
-			IssuedAt:  jwt.NewNumericDate(time.Now()),                                            // 签发时间
+			ID:        jti,                                        // 唯一标识
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expire)), // 过期时间
+			Issuer:    issuer,                                     // 签发者
+			IssuedAt:  jwt.NewNumericDate(time.Now()),             // 签发时间
 		},
 	}
 	//使用 HS256 签名方法创建一个新的 JWT Token
@@ -3,14 +3,15 @@ package jwt
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestGenAndParseToken(t *testing.T) {
 	secret := "secret"
 	issuer := "issuer"
-	expire := 10
+	expire := 10 * time.Minute
 
-	token, err := GenToken(secret, issuer, expire, 1, []int{1, 2})
+	token, err := GenToken(secret, issuer, "jti", expire, 1, []int{1, 2})
 	assert.Nil(t, err)
 	assert.NotEmpty(t, token)
 
@@ -18,6 +19,7 @@ func TestGenAndParseToken(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 1, mc.UserId)
 	assert.Len(t, mc.RoleIds, 2)
+	assert.Equal(t, "jti", mc.ID)
 }
 
 func TestParseTokenError(t *testing.T) {
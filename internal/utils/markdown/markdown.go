@@ -0,0 +1,198 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+/*
+评论使用的受限 Markdown:
+  - 块级: 段落 (段内单个换行渲染为 <br>)、引用 (> 开头)、围栏代码块 (```lang)
+  - 行内: 行内代码 (`code`)、链接 ([文字](url), 只允许 http/https/mailto)、加粗 (**text** / __text__)、斜体 (*text* / _text_)
+  - 不支持原始 HTML、图片、标题、列表等, 这些内容按普通文本展示
+  - 所有文本都会被转义, 渲染结果再经过白名单过滤 (Sanitize), 链接统一加上 rel="nofollow ugc"
+*/
+
+// 引用最多嵌套的层数, 超过的部分按普通文本展示
+const maxQuoteDepth = 3
+
+// 代码块语言只允许简单的标识符, 例如 go、c++、objective-c
+var langRegexp = regexp.MustCompile(`^[A-Za-z0-9_+-]{1,20}$`)
+
+// Render 将评论 Markdown 渲染为安全的 HTML
+func Render(src string) string {
+	return Sanitize(toHTML(src))
+}
+
+// toHTML 将 Markdown 转换为 HTML, 文本内容全部转义
+func toHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), 0)
+	return b.String()
+}
+
+// renderBlocks 渲染块级元素, depth 为当前引用嵌套层数
+func renderBlocks(b *strings.Builder, lines []string, depth int) {
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		b.WriteString("<p>")
+		for i, line := range para {
+			if i > 0 {
+				b.WriteString("<br>")
+			}
+			b.WriteString(renderInline(line))
+		}
+		b.WriteString("</p>")
+		para = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(line, "```"): // 围栏代码块, 未闭合时到内容结尾
+			flush()
+			lang := strings.TrimSpace(line[3:])
+			var code []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "```"; i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code")
+			if langRegexp.MatchString(lang) {
+				b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+			}
+			b.WriteString(">" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>")
+		case strings.HasPrefix(line, ">") && depth < maxQuoteDepth: // 连续的引用行
+			flush()
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimPrefix(strings.TrimSpace(lines[i])[1:], " "))
+			}
+			i--
+			b.WriteString("<blockquote>")
+			renderBlocks(b, quote, depth+1)
+			b.WriteString("</blockquote>")
+		case line == "":
+			flush()
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+}
+
+// renderInline 渲染行内元素, 无法配对的标记按普通文本展示
+func renderInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case '\\': // 转义标记字符
+			if i+1 < len(s) && strings.IndexByte("\\`*_[]()>", s[i+1]) >= 0 {
+				b.WriteString(html.EscapeString(s[i+1 : i+2]))
+				i += 2
+				continue
+			}
+		case '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				b.WriteString("<code>" + html.EscapeString(s[i+1:i+1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+		case '[':
+			if text, href, n, ok := parseLink(s[i:]); ok {
+				b.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(text) + "</a>")
+				i += n
+				continue
+			}
+		case '*', '_':
+			// snake_case 这类单词内部的 _ 不作为标记
+			if c == '_' && i > 0 && isWordByte(s[i-1]) {
+				break
+			}
+			delim := s[i : i+1]
+			if strings.HasPrefix(s[i:], delim+delim) {
+				if end := findClose(s[i+2:], delim+delim); end > 0 {
+					b.WriteString("<strong>" + renderInline(s[i+2:i+2+end]) + "</strong>")
+					i += end + 4
+					continue
+				}
+			} else if end := findClose(s[i+1:], delim); end > 0 {
+				b.WriteString("<em>" + renderInline(s[i+1:i+1+end]) + "</em>")
+				i += end + 2
+				continue
+			}
+		}
+
+		// 普通文本: 一直到下一个可能的标记字符
+		n := strings.IndexAny(s[i+1:], "\\`[*_")
+		if n < 0 {
+			n = len(s) - i - 1
+		}
+		b.WriteString(html.EscapeString(s[i : i+1+n]))
+		i += 1 + n
+	}
+	return b.String()
+}
+
+// findClose 查找强调标记的结束位置, 标记内的文字不能以空格开头或结尾 (避免把 "2 * 3 * 4" 当作斜体)
+func findClose(s, delim string) int {
+	if s == "" || s[0] == ' ' {
+		return -1
+	}
+	for start := 0; ; {
+		end := strings.Index(s[start:], delim)
+		if end < 0 {
+			return -1
+		}
+		end += start
+		if end > 0 && s[end-1] != ' ' {
+			return end
+		}
+		start = end + len(delim)
+	}
+}
+
+// parseLink 解析 [文字](url), 返回链接文字、地址和消耗的字节数
+func parseLink(s string) (text, href string, n int, ok bool) {
+	close := strings.Index(s, "](")
+	if close < 0 || strings.ContainsAny(s[1:close], "[]") {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[close+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	text = s[1:close]
+	href = strings.TrimSpace(s[close+2 : close+2+end])
+	if !SafeURL(href) {
+		return "", "", 0, false
+	}
+	if text == "" {
+		text = href
+	}
+	return text, href, close + 3 + end, true
+}
+
+// SafeURL 链接只允许 http、https、mailto 协议
+func SafeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
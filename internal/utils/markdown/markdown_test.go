@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"hello <b>world</b>", "<p>hello &lt;b&gt;world&lt;/b&gt;</p>"},
+		{"line1\nline2\n\npara2", "<p>line1<br>line2</p><p>para2</p>"},
+		{"use `fmt.Println(\"<hi>\")`", "<p>use <code>fmt.Println(&#34;&lt;hi&gt;&#34;)</code></p>"},
+		{"```go\nif a < b {\n}\n```", `<pre><code class="language-go">if a &lt; b {` + "\n" + `}</code></pre>`},
+		{"```<script>\nx\n```", "<pre><code>x</code></pre>"},
+		{"**bold** and *em* and __b__ _e_", "<p><strong>bold</strong> and <em>em</em> and <strong>b</strong> <em>e</em></p>"},
+		{"2 * 3 * 4, snake_case_name", "<p>2 * 3 * 4, snake_case_name</p>"},
+		{"> quote\n> **more**\n\ntext", "<blockquote><p>quote<br><strong>more</strong></p></blockquote><p>text</p>"},
+		{"[site](https://example.com/?a=1&b=2)", `<p><a href="https://example.com/?a=1&amp;b=2" rel="nofollow ugc">site</a></p>`},
+		{"[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>"},
+		{"[<img>](http://a.com)", `<p><a href="http://a.com" rel="nofollow ugc">&lt;img&gt;</a></p>`},
+		{`\*not em\*`, "<p>*not em*</p>"},
+		{"中文**加粗**", "<p>中文<strong>加粗</strong></p>"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, Render(c.src), c.src)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`<p onclick="x()">hi</p>`, "<p>hi</p>"},
+		{`<script>alert(1)</script>`, "alert(1)"},
+		{`<a href="javascript:alert(1)" rel="opener">x</a>`, `<a rel="nofollow ugc">x</a>`},
+		{`<a href="https://a.com">x</a>`, `<a href="https://a.com" rel="nofollow ugc">x</a>`},
+		{`<code class="foo">x</code>`, "<code>x</code>"},
+		{`<strong><em>x</strong>`, "<strong><em>x</em></strong>"},
+		{`<p>unclosed`, "<p>unclosed</p>"},
+		{`</p>stray<br/>`, "stray<br>"},
+		{`<!-- comment -->text`, "text"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, Sanitize(c.src), c.src)
+	}
+}
@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"golang.org/x/net/html"
+	"regexp"
+	"strings"
+)
+
+// allowTags 允许的标签及其允许的属性, 其余标签去掉 (保留其中的文本), 其余属性一律去掉
+var allowTags = map[string][]string{
+	"p":          nil,
+	"br":         nil,
+	"blockquote": nil,
+	"pre":        nil,
+	"code":       {"class"},
+	"strong":     nil,
+	"em":         nil,
+	"a":          {"href"},
+}
+
+// 没有结束标签的元素
+var voidTags = map[string]bool{"br": true}
+
+var codeClassRegexp = regexp.MustCompile(`^language-[A-Za-z0-9_+-]{1,20}$`)
+
+// Sanitize 按白名单过滤 HTML:
+//   - 只保留 allowTags 中的标签和属性, 注释、脚本等其他内容只保留转义后的文本
+//   - 链接只允许 http/https/mailto, 并统一加上 rel="nofollow ugc"
+//   - 结束标签与开始标签配对, 未闭合的标签在末尾补全
+func Sanitize(s string) string {
+	var b strings.Builder
+	var stack []string
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken: // 结束 (io.EOF)
+			for i := len(stack) - 1; i >= 0; i-- {
+				b.WriteString("</" + stack[i] + ">")
+			}
+			return b.String()
+		case html.TextToken:
+			b.WriteString(html.EscapeString(string(z.Text())))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			attrs, ok := allowTags[tok.Data]
+			if !ok {
+				continue
+			}
+			b.WriteString("<" + tok.Data)
+			for _, attr := range tok.Attr {
+				if allowAttr(tok.Data, attr, attrs) {
+					b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+				}
+			}
+			if tok.Data == "a" {
+				b.WriteString(` rel="nofollow ugc"`)
+			}
+			b.WriteString(">")
+			if !voidTags[tok.Data] {
+				stack = append(stack, tok.Data)
+			}
+		case html.EndTagToken:
+			tok := z.Token()
+			// 关闭到最近的同名标签, 中间未闭合的标签一并关闭
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] != tok.Data {
+					continue
+				}
+				for j := len(stack) - 1; j >= i; j-- {
+					b.WriteString("</" + stack[j] + ">")
+				}
+				stack = stack[:i]
+				break
+			}
+		}
+	}
+}
+
+func allowAttr(tag string, attr html.Attribute, allowed []string) bool {
+	if attr.Namespace != "" {
+		return false
+	}
+	found := false
+	for _, key := range allowed {
+		if key == attr.Key {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	switch {
+	case tag == "a" && attr.Key == "href":
+		return SafeURL(attr.Val)
+	case tag == "code" && attr.Key == "class":
+		return codeClassRegexp.MatchString(attr.Val)
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"gin-blog-server/internal/global"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fakeOIDC 本地模拟的 OIDC 提供方: 授权时记录 code_challenge 和 nonce, 换取令牌时校验 code_verifier
+type fakeOIDC struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	audience  string // 签发 ID Token 的受众, 为空时使用 client id
+}
+
+func newFakeOIDC(t *testing.T) *fakeOIDC {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	f := &fakeOIDC{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"userinfo_endpoint":      f.URL + "/userinfo",
+			"jwks_uri":               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "good-code" || Challenge(r.Form.Get("code_verifier")) != f.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		aud := f.audience
+		if aud == "" {
+			aud = r.Form.Get("client_id")
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   f.URL,
+			"sub":   "user-1",
+			"aud":   aud,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": f.nonce,
+			"name":  "Alice",
+		})
+		token.Header["kid"] = "k1"
+		idToken, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"sub":            "user-1",
+			"email":          "alice@example.com",
+			"email_verified": "true",
+			"picture":        "https://example.com/a.png",
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// authorize 模拟用户在提供方完成授权: 记录授权链接中的 PKCE 和 nonce 参数
+func (f *fakeOIDC) authorize(t *testing.T, p Provider, req AuthRequest) {
+	raw, err := p.AuthURL(context.Background(), req)
+	assert.Nil(t, err)
+	u, _ := url.Parse(raw)
+	assert.Equal(t, f.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, req.State, u.Query().Get("state"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	f.challenge = u.Query().Get("code_challenge")
+	f.nonce = u.Query().Get("nonce")
+}
+
+func TestOIDC(t *testing.T) {
+	f := newFakeOIDC(t)
+	p, err := NewProvider("local", global.OAuthProvider{Type: "oidc", Issuer: f.URL, ClientID: "blog"}, "http://blog/callback")
+	assert.Nil(t, err)
+
+	req := NewAuthRequest()
+	f.authorize(t, p, req)
+	id, err := p.Identify(context.Background(), "good-code", req)
+	assert.Nil(t, err)
+	assert.Equal(t, &Identity{
+		Subject:       "user-1",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Nickname:      "Alice",
+		Avatar:        "https://example.com/a.png",
+	}, id)
+
+	// PKCE code_verifier 不匹配
+	_, err = p.Identify(context.Background(), "good-code", AuthRequest{Verifier: "other", Nonce: req.Nonce})
+	assert.NotNil(t, err)
+
+	// nonce 不匹配
+	_, err = p.Identify(context.Background(), "good-code", AuthRequest{Verifier: req.Verifier, Nonce: "other"})
+	assert.NotNil(t, err)
+
+	// 受众不是当前应用
+	f.audience = "another-app"
+	_, err = p.Identify(context.Background(), "good-code", req)
+	assert.NotNil(t, err)
+}
+
+func TestGitHub(t *testing.T) {
+	var challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if Challenge(r.Form.Get("code_verifier")) != challenge {
+			w.Write([]byte("error=bad_verification_code"))
+			return
+		}
+		// 不指定 JSON 时 GitHub 返回 form 格式
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=gh&token_type=bearer"))
+	})
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gh", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"id": 42, "login": "octocat", "name": "", "avatar_url": "https://example.com/o.png"}`))
+	})
+	mux.HandleFunc("/api/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "unverified@example.com", "primary": false, "verified": false},
+			{"email": "octo@example.com", "primary": true, "verified": true}]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p, err := NewProvider("github", global.OAuthProvider{
+		ClientID: "id",
+		AuthURL:  srv.URL + "/login/oauth/authorize",
+		TokenURL: srv.URL + "/login/oauth/access_token",
+		APIURL:   srv.URL + "/api",
+	}, "http://blog/callback")
+	assert.Nil(t, err)
+
+	req := NewAuthRequest()
+	raw, _ := p.AuthURL(context.Background(), req)
+	u, _ := url.Parse(raw)
+	assert.Equal(t, "read:user user:email", u.Query().Get("scope"))
+	challenge = u.Query().Get("code_challenge")
+
+	id, err := p.Identify(context.Background(), "code", req)
+	assert.Nil(t, err)
+	assert.Equal(t, &Identity{
+		Subject:       "42",
+		Email:         "octo@example.com",
+		EmailVerified: true,
+		Nickname:      "octocat",
+		Avatar:        "https://example.com/o.png",
+	}, id)
+}
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider("github", global.OAuthProvider{}, "")
+	assert.NotNil(t, err)
+	_, err = NewProvider("unknown", global.OAuthProvider{ClientID: "id"}, "")
+	assert.NotNil(t, err)
+	_, err = NewProvider("keycloak", global.OAuthProvider{Type: "oidc", ClientID: "id"}, "")
+	assert.NotNil(t, err)
+
+	p, err := NewProvider("qq", global.OAuthProvider{ClientID: "id"}, "http://blog/callback")
+	assert.Nil(t, err)
+	raw, _ := p.AuthURL(context.Background(), NewAuthRequest())
+	u, _ := url.Parse(raw)
+	assert.Equal(t, "graph.qq.com", u.Host)
+	assert.Equal(t, "get_user_info", u.Query().Get("scope"))
+	assert.Equal(t, "http://blog/callback", u.Query().Get("redirect_uri"))
+}
+
+func TestChallenge(t *testing.T) {
+	// RFC 7636 附录 B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	req := NewAuthRequest()
+	assert.Len(t, req.Verifier, 43)
+	assert.NotEqual(t, req.State, NewAuthRequest().State)
+}
@@ -0,0 +1,260 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// OIDC 通用 OpenID Connect 登录
+// 通过 {Issuer}/.well-known/openid-configuration 获取各端点, 登录时校验 ID Token
+type OIDC struct {
+	client
+	issuer string
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]crypto.PublicKey // kid => 公钥
+}
+
+// oidcDiscovery OIDC 提供方元数据
+type oidcDiscovery struct {
+	Issuer           string `json:"issuer"`
+	AuthEndpoint     string `json:"authorization_endpoint"`
+	TokenEndpoint    string `json:"token_endpoint"`
+	UserInfoEndpoint string `json:"userinfo_endpoint"`
+	JwksURI          string `json:"jwks_uri"`
+}
+
+// oidcClaims ID Token 和 userinfo 中的用户信息
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"` // 部分提供方返回字符串 "true"
+	Name              string `json:"name"`
+	Nickname          string `json:"nickname"`
+	PreferredUsername string `json:"preferred_username"`
+	Picture           string `json:"picture"`
+}
+
+func newOIDC(c client, issuer string) *OIDC {
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "profile", "email"}
+	}
+	return &OIDC{client: c, issuer: strings.TrimSuffix(issuer, "/")}
+}
+
+// discover 获取提供方元数据, 成功后缓存; 配置中指定的授权/令牌地址优先
+func (o *OIDC) discover(ctx context.Context) (*oidcDiscovery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+
+	var d oidcDiscovery
+	if err := o.getJSON(ctx, o.issuer+"/.well-known/openid-configuration", "", &d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != o.issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer mismatch %q", d.Issuer)
+	}
+	if d.AuthEndpoint == "" || d.TokenEndpoint == "" || d.JwksURI == "" {
+		return nil, errors.New("oidc discovery: missing endpoints")
+	}
+	if o.AuthEndpoint == "" {
+		o.AuthEndpoint = d.AuthEndpoint
+	}
+	if o.TokenEndpoint == "" {
+		o.TokenEndpoint = d.TokenEndpoint
+	}
+	o.discovery = &d
+	return o.discovery, nil
+}
+
+func (o *OIDC) AuthURL(ctx context.Context, req AuthRequest) (string, error) {
+	if _, err := o.discover(ctx); err != nil {
+		return "", err
+	}
+	return o.authURL(req, url.Values{"nonce": {req.Nonce}}), nil
+}
+
+func (o *OIDC) Identify(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	d, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := o.exchange(ctx, code, req.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+
+	claims, err := o.verifyIDToken(ctx, d, token.IDToken, req.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// ID Token 中没有邮箱时从 userinfo 接口获取, sub 必须与 ID Token 一致
+	if claims.Email == "" && d.UserInfoEndpoint != "" {
+		var info oidcClaims
+		if err := o.getJSON(ctx, d.UserInfoEndpoint, token.AccessToken, &info); err == nil && info.Subject == claims.Subject {
+			claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+			claims.Name = firstNonEmpty(claims.Name, info.Name)
+			claims.Nickname = firstNonEmpty(claims.Nickname, info.Nickname)
+			claims.PreferredUsername = firstNonEmpty(claims.PreferredUsername, info.PreferredUsername)
+			claims.Picture = firstNonEmpty(claims.Picture, info.Picture)
+		}
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.Email != "" && isTrue(claims.EmailVerified),
+		Nickname:      firstNonEmpty(claims.Name, claims.Nickname, claims.PreferredUsername),
+		Avatar:        claims.Picture,
+	}, nil
+}
+
+// verifyIDToken 校验 ID Token 的签名、签发者、受众、有效期和 nonce
+func (o *OIDC) verifyIDToken(ctx context.Context, d *oidcDiscovery, raw, nonce string) (*oidcClaims, error) {
+	var claims oidcClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return o.key(ctx, d, kid)
+	}, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+	if err != nil {
+		return nil, fmt.Errorf("oidc id_token: %w", err)
+	}
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, errors.New("oidc id_token: issuer mismatch")
+	}
+	if !claims.VerifyAudience(o.ClientID, true) {
+		return nil, errors.New("oidc id_token: audience mismatch")
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("oidc id_token: missing exp")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("oidc id_token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errNoSubject
+	}
+	return &claims, nil
+}
+
+// key 根据 kid 获取签名公钥, 找不到时重新获取一次 JWKS (提供方可能轮换了密钥)
+func (o *OIDC) key(ctx context.Context, d *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if key, ok := o.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := o.getJSON(ctx, d.JwksURI, "", &jwks); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	o.keys = make(map[string]crypto.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			o.keys[k.Kid] = key
+		}
+	}
+	if key, ok := o.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc jwks: key %q not found", kid)
+}
+
+// lookupKey kid 为空且只有一个公钥时使用该公钥
+func (o *OIDC) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if key, ok := o.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(o.keys) == 1 {
+		for _, key := range o.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// jwk JSON Web Key, 只支持 RSA 和 EC 公钥
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func isTrue(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
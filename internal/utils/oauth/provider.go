@@ -0,0 +1,205 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GitHub GitHub 登录
+// 用户接口返回的 email 是公开邮箱, 不一定已验证, 因此从 /user/emails 中取已验证的主邮箱
+type GitHub struct {
+	client
+	api string
+}
+
+func newGitHub(c client, api string) *GitHub {
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	return &GitHub{
+		client: c.withDefaults("https://github.com/login/oauth/authorize", "https://github.com/login/oauth/access_token",
+			"read:user", "user:email"),
+		api: strings.TrimSuffix(api, "/"),
+	}
+}
+
+func (g *GitHub) AuthURL(_ context.Context, req AuthRequest) (string, error) {
+	return g.authURL(req, nil), nil
+}
+
+func (g *GitHub) Identify(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	token, err := g.exchange(ctx, code, req.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := g.getJSON(ctx, g.api+"/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errNoSubject
+	}
+	id := &Identity{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Nickname: firstNonEmpty(user.Name, user.Login),
+		Avatar:   user.AvatarURL,
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	// 没有授权 user:email 时获取失败, 不影响登录, 只是无法通过邮箱关联已有账号
+	if err := g.getJSON(ctx, g.api+"/user/emails", token.AccessToken, &emails); err == nil {
+		for _, e := range emails {
+			if e.Verified && (e.Primary || id.Email == "") {
+				id.Email, id.EmailVerified = e.Email, true
+			}
+		}
+	}
+	return id, nil
+}
+
+// Gitee 码云登录
+type Gitee struct {
+	client
+	api string
+}
+
+func newGitee(c client, api string) *Gitee {
+	if api == "" {
+		api = "https://gitee.com/api/v5"
+	}
+	return &Gitee{
+		client: c.withDefaults("https://gitee.com/oauth/authorize", "https://gitee.com/oauth/token", "user_info", "emails"),
+		api:    strings.TrimSuffix(api, "/"),
+	}
+}
+
+func (g *Gitee) AuthURL(_ context.Context, req AuthRequest) (string, error) {
+	return g.authURL(req, nil), nil
+}
+
+func (g *Gitee) Identify(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	token, err := g.exchange(ctx, code, req.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	// 码云通过 access_token 参数传递令牌
+	query := "?" + url.Values{"access_token": {token.AccessToken}}.Encode()
+	if err := g.getJSON(ctx, g.api+"/user"+query, "", &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errNoSubject
+	}
+	id := &Identity{
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Nickname: firstNonEmpty(user.Name, user.Login),
+		Avatar:   user.AvatarURL,
+	}
+
+	var emails []struct {
+		Email string   `json:"email"`
+		State string   `json:"state"` // confirmed 表示已验证
+		Scope []string `json:"scope"`
+	}
+	if err := g.getJSON(ctx, g.api+"/emails"+query, "", &emails); err == nil {
+		for _, e := range emails {
+			if e.State != "confirmed" {
+				continue
+			}
+			if id.Email == "" || slices.Contains(e.Scope, "primary") {
+				id.Email, id.EmailVerified = e.Email, true
+			}
+		}
+	}
+	return id, nil
+}
+
+// QQ QQ 互联登录
+// 需要先通过 /oauth2.0/me 获取 openid, QQ 不提供邮箱, 因此只能自动创建账号
+type QQ struct {
+	client
+	api string
+}
+
+func newQQ(c client, api string) *QQ {
+	if api == "" {
+		api = "https://graph.qq.com"
+	}
+	api = strings.TrimSuffix(api, "/")
+	c = c.withDefaults(api+"/oauth2.0/authorize", api+"/oauth2.0/token", "get_user_info")
+	c.ScopeSep = ","
+	return &QQ{client: c, api: api}
+}
+
+func (q *QQ) AuthURL(_ context.Context, req AuthRequest) (string, error) {
+	return q.authURL(req, nil), nil
+}
+
+func (q *QQ) Identify(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	token, err := q.exchange(ctx, code, req.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var me struct {
+		ClientID string `json:"client_id"`
+		OpenID   string `json:"openid"`
+	}
+	query := url.Values{"access_token": {token.AccessToken}, "fmt": {"json"}}
+	if err := q.getJSON(ctx, q.api+"/oauth2.0/me?"+query.Encode(), "", &me); err != nil {
+		return nil, err
+	}
+	if me.OpenID == "" {
+		return nil, errNoSubject
+	}
+
+	var user struct {
+		Ret          int    `json:"ret"`
+		Msg          string `json:"msg"`
+		Nickname     string `json:"nickname"`
+		FigureURL    string `json:"figureurl_qq_1"` // 40x40
+		FigureURLBig string `json:"figureurl_qq_2"` // 100x100, 部分用户没有
+	}
+	query = url.Values{"access_token": {token.AccessToken}, "oauth_consumer_key": {q.ClientID}, "openid": {me.OpenID}}
+	if err := q.getJSON(ctx, q.api+"/user/get_user_info?"+query.Encode(), "", &user); err != nil {
+		return nil, err
+	}
+	if user.Ret != 0 {
+		return nil, fmt.Errorf("qq get_user_info: %d %s", user.Ret, user.Msg)
+	}
+	return &Identity{
+		Subject:  me.OpenID,
+		Nickname: user.Nickname,
+		Avatar:   firstNonEmpty(user.FigureURLBig, user.FigureURL),
+	}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
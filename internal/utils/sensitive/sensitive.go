@@ -0,0 +1,200 @@
+package sensitive
+
+import (
+	"sync/atomic"
+	"unicode"
+)
+
+/*
+敏感词过滤:
+  - 使用 Aho-Corasick 自动机 (带失败指针的 Trie / DFA), 一次扫描即可匹配所有敏感词
+  - 匹配前对字符进行归一化: 全角转半角、大小写统一
+  - 忽略空白、标点、符号及零宽字符, "敏 感-词"、"ＢＡＤ" 都能匹配到 "敏感词"、"bad"
+  - 每个敏感词带有处理方式: 替换为 *、转为人工审核、直接拒绝, 多个敏感词命中时取最严格的处理方式
+  - 词库通过 Load 原子替换, 修改词库后无需重启
+*/
+
+// Action 命中敏感词后的处理方式, 数值越大越严格
+type Action int
+
+const (
+	ACTION_NONE   Action = iota // 未命中
+	ACTION_MASK                 // 替换为 *
+	ACTION_REVIEW               // 转为人工审核
+	ACTION_REJECT               // 直接拒绝
+)
+
+// Word 敏感词及其处理方式
+type Word struct {
+	Word   string
+	Action Action
+}
+
+// Result 过滤结果
+type Result struct {
+	Text   string   // 处理后的文本 (ACTION_MASK 的敏感词已替换为 *)
+	Action Action   // 命中敏感词中最严格的处理方式
+	Words  []string // 命中的敏感词
+}
+
+// normalize 字符归一化, 返回 false 表示该字符在匹配时忽略
+func normalize(r rune) (rune, bool) {
+	switch {
+	case r == '　': // 全角空格
+		return 0, false
+	case r >= '！' && r <= '～': // 全角 ASCII 转半角
+		r -= 0xfee0
+	}
+	if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.Is(unicode.Cf, r) {
+		return 0, false
+	}
+	return unicode.ToLower(r), true
+}
+
+type node struct {
+	next map[rune]int
+	fail int
+	out  []int // 在该节点结束的敏感词 (包括失败指针上的)
+}
+
+type pattern struct {
+	word   string
+	length int // 归一化后的长度
+	action Action
+}
+
+// Matcher 敏感词匹配器, 构建后只读, 可以并发使用
+type Matcher struct {
+	nodes    []node
+	patterns []pattern
+}
+
+// NewMatcher 根据敏感词构建匹配器
+// 同一个词出现多次时以最严格的处理方式为准
+func NewMatcher(words []Word) *Matcher {
+	m := &Matcher{nodes: []node{{next: map[rune]int{}}}}
+	index := make(map[string]int) // 归一化后的词 -> pattern 下标
+
+	for _, w := range words {
+		var key []rune
+		for _, r := range w.Word {
+			if n, ok := normalize(r); ok {
+				key = append(key, n)
+			}
+		}
+		if len(key) == 0 || w.Action <= ACTION_NONE {
+			continue
+		}
+		if i, ok := index[string(key)]; ok {
+			m.patterns[i].action = max(m.patterns[i].action, w.Action)
+			continue
+		}
+
+		cur := 0
+		for _, r := range key {
+			nx, ok := m.nodes[cur].next[r]
+			if !ok {
+				nx = len(m.nodes)
+				m.nodes = append(m.nodes, node{next: map[rune]int{}})
+				m.nodes[cur].next[r] = nx
+			}
+			cur = nx
+		}
+		index[string(key)] = len(m.patterns)
+		m.nodes[cur].out = append(m.nodes[cur].out, len(m.patterns))
+		m.patterns = append(m.patterns, pattern{word: w.Word, length: len(key), action: w.Action})
+	}
+
+	// BFS 构建失败指针
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for fail != 0 && !m.has(fail, r) {
+				fail = m.nodes[fail].fail
+			}
+			if nx, ok := m.nodes[fail].next[r]; ok && nx != child {
+				fail = nx
+			} else {
+				fail = 0
+			}
+			m.nodes[child].fail = fail
+			m.nodes[child].out = append(m.nodes[child].out, m.nodes[fail].out...)
+			queue = append(queue, child)
+		}
+	}
+	return m
+}
+
+func (m *Matcher) has(state int, r rune) bool {
+	_, ok := m.nodes[state].next[r]
+	return ok
+}
+
+// Filter 匹配并处理文本中的敏感词
+func (m *Matcher) Filter(text string) Result {
+	result := Result{Text: text}
+	if m == nil || len(m.patterns) == 0 {
+		return result
+	}
+
+	runes := []rune(text)
+	pos := make([]int, 0, len(runes)) // 参与匹配的字符在原文中的下标
+	masked := make([]bool, len(runes))
+	hit := make(map[int]bool)
+	state := 0
+
+	for i, r := range runes {
+		n, ok := normalize(r)
+		if !ok {
+			continue
+		}
+		pos = append(pos, i)
+		for state != 0 && !m.has(state, n) {
+			state = m.nodes[state].fail
+		}
+		state = m.nodes[state].next[n] // 不存在时为 0, 即回到根节点
+
+		for _, p := range m.nodes[state].out {
+			pat := m.patterns[p]
+			if !hit[p] {
+				hit[p] = true
+				result.Words = append(result.Words, pat.word)
+			}
+			result.Action = max(result.Action, pat.action)
+			if pat.action == ACTION_MASK {
+				for j := pos[len(pos)-pat.length]; j <= i; j++ {
+					masked[j] = true
+				}
+			}
+		}
+	}
+
+	if len(result.Words) > 0 {
+		for i := range runes {
+			if masked[i] {
+				runes[i] = '*'
+			}
+		}
+		result.Text = string(runes)
+	}
+	return result
+}
+
+// 当前使用的匹配器
+var current atomic.Pointer[Matcher]
+
+// Load 使用新的词库替换当前匹配器
+func Load(words []Word) {
+	current.Store(NewMatcher(words))
+}
+
+// Filter 使用当前词库匹配并处理文本, 词库未加载时原样返回
+func Filter(text string) Result {
+	return current.Load().Filter(text)
+}
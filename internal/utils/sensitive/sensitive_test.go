@@ -0,0 +1,55 @@
+package sensitive
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	m := NewMatcher([]Word{
+		{Word: "坏蛋", Action: ACTION_MASK},
+		{Word: "bad", Action: ACTION_MASK},
+		{Word: "广告", Action: ACTION_REVIEW},
+		{Word: "赌博", Action: ACTION_REJECT},
+	})
+
+	r := m.Filter("你这个坏蛋")
+	assert.Equal(t, "你这个**", r.Text)
+	assert.Equal(t, ACTION_MASK, r.Action)
+	assert.Equal(t, []string{"坏蛋"}, r.Words)
+
+	// 插入空格、标点, 大小写、全角
+	assert.Equal(t, "so *****!", m.Filter("so B A-D!").Text)
+	assert.Equal(t, "***", m.Filter("ＢＡｄ").Text)
+	assert.Equal(t, "坏***", m.Filter("坏坏 蛋").Text)
+
+	r = m.Filter("看广告, 来赌 博")
+	assert.Equal(t, ACTION_REJECT, r.Action)
+	assert.Equal(t, "看广告, 来赌 博", r.Text) // 只有 ACTION_MASK 的敏感词会被替换
+	assert.ElementsMatch(t, []string{"广告", "赌博"}, r.Words)
+
+	r = m.Filter("正常内容")
+	assert.Equal(t, ACTION_NONE, r.Action)
+	assert.Empty(t, r.Words)
+}
+
+func TestOverlap(t *testing.T) {
+	// 失败指针: "she" 中包含 "he"
+	m := NewMatcher([]Word{
+		{Word: "he", Action: ACTION_MASK},
+		{Word: "she", Action: ACTION_REVIEW},
+		{Word: "hers", Action: ACTION_MASK},
+	})
+	r := m.Filter("ushers")
+	assert.Equal(t, ACTION_REVIEW, r.Action)
+	assert.ElementsMatch(t, []string{"he", "she", "hers"}, r.Words)
+	assert.Equal(t, "us****", r.Text)
+}
+
+func TestLoad(t *testing.T) {
+	assert.Equal(t, "abc", Filter("abc").Text) // 未加载词库
+	Load([]Word{{Word: "abc", Action: ACTION_MASK}})
+	assert.Equal(t, "***", Filter("abc").Text)
+	Load(nil)
+	assert.Equal(t, "abc", Filter("abc").Text)
+}
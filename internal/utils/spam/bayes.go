@@ -0,0 +1,243 @@
+package spam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+朴素贝叶斯分类器:
+  - 训练数据保存在 Redis 中, 统计每个词在 垃圾/正常 文档中出现的文档数, 以及两类文档的总数
+  - 分词: 英文、数字按单词切分, 中文按相邻两个字 (bigram) 切分
+  - 每条内容只记录一次训练结果 (label) 以及训练时的词, 管理员改变判断或内容被编辑后重新训练时,
+    按记录的词撤销之前的训练, 保证词频统计不会偏移
+*/
+
+// Redis key
+const (
+	KEY_BAYES_SPAM  = "spam:bayes:spam"  // 词 -> 包含该词的垃圾文档数
+	KEY_BAYES_HAM   = "spam:bayes:ham"   // 词 -> 包含该词的正常文档数
+	KEY_BAYES_DOCS  = "spam:bayes:docs"  // spam | ham -> 文档总数
+	KEY_BAYES_LABEL = "spam:bayes:label" // 内容 id -> 训练记录 (trainedDoc 的 JSON; 旧数据只有分类)
+)
+
+const (
+	labelSpam = "spam"
+	labelHam  = "ham"
+)
+
+// 单条内容最多参与计算的词数
+const maxTokens = 200
+
+// Tokenize 分词并去重
+func Tokenize(text string) []string {
+	seen := make(map[string]bool)
+	tokens := make([]string, 0)
+	add := func(token string) {
+		if !seen[token] && len(tokens) < maxTokens {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	var word []rune
+	var han []rune
+	flush := func() {
+		if len(word) >= 2 {
+			add(strings.ToLower(string(word)))
+		}
+		if len(han) == 1 {
+			add(string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		word, han = word[:0], han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// BayesStats 训练数据统计
+type BayesStats struct {
+	SpamDocs int64
+	HamDocs  int64
+	Spam     map[string]int64 // 包含该词的垃圾文档数
+	Ham      map[string]int64 // 包含该词的正常文档数
+}
+
+// Score 计算内容为垃圾内容的概率
+// 两类训练数据都存在, 且内容中至少有一个词出现在训练数据中时才计算, 否则返回 0
+func (s BayesStats) Score(tokens []string) float64 {
+	if s.SpamDocs <= 0 || s.HamDocs <= 0 {
+		return 0
+	}
+	logOdds := math.Log(float64(s.SpamDocs) / float64(s.HamDocs))
+	matched := 0
+	for _, t := range tokens {
+		spam, ham := max(s.Spam[t], 0), max(s.Ham[t], 0)
+		if spam == 0 && ham == 0 {
+			continue
+		}
+		matched++
+		// 拉普拉斯平滑
+		pSpam := (float64(spam) + 1) / (float64(s.SpamDocs) + 2)
+		pHam := (float64(ham) + 1) / (float64(s.HamDocs) + 2)
+		logOdds += math.Log(pSpam / pHam)
+	}
+	if matched == 0 {
+		return 0
+	}
+	return 1 / (1 + math.Exp(-logOdds))
+}
+
+// Bayes 基于 Redis 的朴素贝叶斯分类器
+type Bayes struct {
+	RDB *redis.Client
+}
+
+func (b Bayes) Name() string { return "bayes" }
+
+func (b Bayes) Check(ctx context.Context, item Item) (float64, error) {
+	tokens := Tokenize(item.Content)
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+
+	docs, err := b.RDB.HMGet(ctx, KEY_BAYES_DOCS, labelSpam, labelHam).Result()
+	if err != nil {
+		return 0, err
+	}
+	spam, err := b.RDB.HMGet(ctx, KEY_BAYES_SPAM, tokens...).Result()
+	if err != nil {
+		return 0, err
+	}
+	ham, err := b.RDB.HMGet(ctx, KEY_BAYES_HAM, tokens...).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	stats := BayesStats{
+		SpamDocs: toInt64(docs[0]),
+		HamDocs:  toInt64(docs[1]),
+		Spam:     make(map[string]int64, len(tokens)),
+		Ham:      make(map[string]int64, len(tokens)),
+	}
+	for i, t := range tokens {
+		stats.Spam[t] = toInt64(spam[i])
+		stats.Ham[t] = toInt64(ham[i])
+	}
+	return stats.Score(tokens), nil
+}
+
+// trainedDoc 一条内容的训练记录
+type trainedDoc struct {
+	Label  string   `json:"label"`
+	Tokens []string `json:"tokens"`
+}
+
+// parseTrainedDoc 解析训练记录, 兼容只保存了分类的旧数据 (Tokens 为 nil)
+func parseTrainedDoc(s string) trainedDoc {
+	var doc trainedDoc
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		return trainedDoc{Label: s}
+	}
+	return doc
+}
+
+// 并发训练同一批数据时 (WATCH 冲突) 的最大重试次数
+const maxTrainRetries = 3
+
+// Train 使用管理员的审核结果训练分类器
+// id 为内容的唯一标识 (例如 "comment:1"), 同一内容重复训练相同结果和内容时忽略,
+// 结果或内容改变时按之前记录的词撤销之前的训练
+func (b Bayes) Train(ctx context.Context, id, text string, spam bool) error {
+	doc := trainedDoc{Label: labelHam, Tokens: Tokenize(text)}
+	if spam {
+		doc.Label = labelSpam
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	train := func(tx *redis.Tx) error {
+		s, err := tx.HGet(ctx, KEY_BAYES_LABEL, id).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		var prev trainedDoc
+		if s != "" {
+			prev = parseTrainedDoc(s)
+			if prev.Tokens == nil { // 旧数据没有记录训练时的词, 只能使用当前内容的词
+				prev.Tokens = doc.Tokens
+			}
+		}
+		if prev.Label == doc.Label && slices.Equal(prev.Tokens, doc.Tokens) {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if prev.Label != "" {
+				incrTokens(ctx, pipe, prev.Label, prev.Tokens, -1)
+			}
+			incrTokens(ctx, pipe, doc.Label, doc.Tokens, 1)
+			pipe.HSet(ctx, KEY_BAYES_LABEL, id, data)
+			return nil
+		})
+		return err
+	}
+
+	for range maxTrainRetries {
+		err = b.RDB.Watch(ctx, train, KEY_BAYES_LABEL)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return err
+}
+
+// incrTokens 更新某一类的词频统计与文档数
+func incrTokens(ctx context.Context, pipe redis.Pipeliner, label string, tokens []string, delta int64) {
+	key := KEY_BAYES_HAM
+	if label == labelSpam {
+		key = KEY_BAYES_SPAM
+	}
+	for _, t := range tokens {
+		pipe.HIncrBy(ctx, key, t, delta)
+	}
+	pipe.HIncrBy(ctx, KEY_BAYES_DOCS, label, delta)
+}
+
+func toInt64(v any) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
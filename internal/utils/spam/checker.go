@@ -0,0 +1,130 @@
+package spam
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/redis/go-redis/v9"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Redis key
+const (
+	KEY_FINGERPRINT   = "spam:fingerprint:"   // 内容指纹出现次数
+	KEY_VELOCITY_IP   = "spam:velocity:ip:"   // IP 发布次数
+	KEY_VELOCITY_USER = "spam:velocity:user:" // 用户发布次数
+)
+
+var linkRegexp = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// CountLinks 统计内容中的链接数量
+func CountLinks(content string) int {
+	return len(linkRegexp.FindAllStringIndex(content, -1))
+}
+
+// LinkChecker 链接数量检测: 链接数达到 Max 时分数为 1, 否则按比例计算
+type LinkChecker struct {
+	Max int
+}
+
+func (l LinkChecker) Name() string { return "link" }
+
+func (l LinkChecker) Check(_ context.Context, item Item) (float64, error) {
+	if l.Max <= 0 {
+		return 0, nil
+	}
+	return float64(CountLinks(item.Content)) / float64(l.Max), nil
+}
+
+// 参与指纹计算的最小长度, 过短的内容 ("好文", "沙发") 重复很正常
+const minFingerprintLen = 10
+
+// Fingerprint 计算内容指纹: 只保留字母和数字并转为小写, 忽略空白、标点等差异
+// 内容过短时返回空字符串
+func Fingerprint(content string) string {
+	var sb strings.Builder
+	n := 0
+	for _, r := range content {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(unicode.ToLower(r))
+			n++
+		}
+	}
+	if n < minFingerprintLen {
+		return ""
+	}
+	sum := sha1.Sum([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// DuplicateChecker 重复内容检测: 时间窗口内出现过相同指纹的内容分数为 1
+// 编辑后指纹不变 (只修改了标点、空白等) 时不检测, 避免与编辑前的内容本身重复
+type DuplicateChecker struct {
+	RDB    *redis.Client
+	Window time.Duration
+}
+
+func (d DuplicateChecker) Name() string { return "duplicate" }
+
+func (d DuplicateChecker) Check(ctx context.Context, item Item) (float64, error) {
+	fp := Fingerprint(item.Content)
+	if fp == "" || (item.Previous != "" && fp == Fingerprint(item.Previous)) {
+		return 0, nil
+	}
+	count, err := incrWithin(ctx, d.RDB, KEY_FINGERPRINT+fp, d.Window)
+	if err != nil || count <= 1 {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// VelocityChecker 发布频率检测: 时间窗口内同一 IP 或同一用户发布超过 Limit 次时分数为 1, 编辑不计入
+type VelocityChecker struct {
+	RDB    *redis.Client
+	Limit  int
+	Window time.Duration
+}
+
+func (v VelocityChecker) Name() string { return "velocity" }
+
+func (v VelocityChecker) Check(ctx context.Context, item Item) (float64, error) {
+	if v.Limit <= 0 || item.Previous != "" {
+		return 0, nil
+	}
+	keys := make([]string, 0, 2)
+	if item.IP != "" {
+		keys = append(keys, KEY_VELOCITY_IP+item.IP)
+	}
+	if item.UserId != 0 {
+		keys = append(keys, KEY_VELOCITY_USER+strconv.Itoa(item.UserId))
+	}
+	for _, key := range keys {
+		count, err := incrWithin(ctx, v.RDB, key, v.Window)
+		if err != nil {
+			return 0, err
+		}
+		if count > int64(v.Limit) {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// incrWithinScript 计数 +1, 没有过期时间时设置 (第一次计数, 或之前设置失败的计数)
+// KEYS[1]: 计数 key, ARGV[1]: 窗口长度 (毫秒)
+var incrWithinScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// incrWithin 计数 +1, 第一次计数时设置过期时间 (固定时间窗口); 通过 Lua 脚本原子执行, 计数不会因为设置过期时间失败而永久保留
+func incrWithin(ctx context.Context, rdb *redis.Client, key string, window time.Duration) (int64, error) {
+	return incrWithinScript.Run(ctx, rdb, []string{key}, window.Milliseconds()).Int64()
+}
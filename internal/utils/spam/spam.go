@@ -0,0 +1,75 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+/*
+垃圾内容检测管道:
+  - 管道由多个检测器 (Checker) 组成, 每个检测器给出 [0, 1] 的分数, 分数越高越可能是垃圾内容
+  - 最终分数取所有检测器分数的最大值, 由调用方根据阈值决定放行、进入人工审核或直接拒绝
+  - 检测器出错时跳过该检测器 (记录日志), 不影响正常发布
+
+内置检测器:
+  - LinkChecker: 链接数量
+  - DuplicateChecker: 重复内容指纹
+  - VelocityChecker: 同一 IP / 用户的发布频率
+  - Bayes: 可训练的朴素贝叶斯分类器, 管理员的审核结果作为训练数据
+*/
+
+// 内容类型
+const (
+	KIND_COMMENT = "comment"
+	KIND_MESSAGE = "message"
+)
+
+// Item 待检测的内容
+type Item struct {
+	Kind    string // 内容类型 comment | message
+	Content string
+	UserId  int // 发布者, 游客为 0
+	IP      string
+	// 编辑时为编辑前的内容, 新发布时为空; 编辑不计入发布频率, 与编辑前指纹相同时也不算重复内容
+	Previous string
+}
+
+// Checker 垃圾内容检测器
+type Checker interface {
+	Name() string
+	Check(ctx context.Context, item Item) (float64, error)
+}
+
+// Result 检测结果
+type Result struct {
+	Score   float64  // 最终分数
+	Reasons []string // 命中的检测器及其分数
+}
+
+// Pipeline 组合多个检测器
+type Pipeline struct {
+	checkers []Checker
+}
+
+func NewPipeline(checkers ...Checker) *Pipeline {
+	return &Pipeline{checkers: checkers}
+}
+
+// Check 依次执行所有检测器, 最终分数取最大值
+func (p *Pipeline) Check(ctx context.Context, item Item) Result {
+	var result Result
+	for _, checker := range p.checkers {
+		score, err := checker.Check(ctx, item)
+		if err != nil {
+			slog.Warn("垃圾内容检测失败", "checker", checker.Name(), "err", err)
+			continue
+		}
+		if score <= 0 {
+			continue
+		}
+		result.Reasons = append(result.Reasons, fmt.Sprintf("%s:%.2f", checker.Name(), score))
+		result.Score = max(result.Score, min(score, 1))
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type fakeChecker struct {
+	name  string
+	score float64
+	err   error
+}
+
+func (f fakeChecker) Name() string { return f.name }
+
+func (f fakeChecker) Check(context.Context, Item) (float64, error) { return f.score, f.err }
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline(
+		fakeChecker{name: "a", score: 0.3},
+		fakeChecker{name: "b", score: 2},
+		fakeChecker{name: "c", err: errors.New("redis down")},
+		fakeChecker{name: "d"},
+	)
+	result := p.Check(context.Background(), Item{Content: "hello"})
+	assert.Equal(t, 1.0, result.Score)
+	assert.Equal(t, []string{"a:0.30", "b:2.00"}, result.Reasons)
+
+	assert.Zero(t, NewPipeline().Check(context.Background(), Item{}).Score)
+}
+
+func TestLinkChecker(t *testing.T) {
+	assert.Equal(t, 3, CountLinks("http://a.com https://b.com www.c.com"))
+	score, _ := LinkChecker{Max: 2}.Check(context.Background(), Item{Content: "see http://a.com"})
+	assert.Equal(t, 0.5, score)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, "", Fingerprint("好文!"))
+	assert.Equal(t, Fingerprint("Buy cheap watches now"), Fingerprint("buy  CHEAP watches, now!!"))
+	assert.NotEqual(t, Fingerprint("Buy cheap watches now"), Fingerprint("Buy cheap phones now"))
+}
+
+func TestEditChecks(t *testing.T) {
+	// 编辑只修改标点、空白时指纹不变, 不访问 Redis 计数
+	score, err := DuplicateChecker{}.Check(context.Background(), Item{Content: "Buy cheap watches now!", Previous: "buy cheap watches now"})
+	assert.Nil(t, err)
+	assert.Zero(t, score)
+	// 编辑不计入发布频率
+	score, err = VelocityChecker{Limit: 1}.Check(context.Background(), Item{Content: "hello", UserId: 1, IP: "1.2.3.4", Previous: "hi"})
+	assert.Nil(t, err)
+	assert.Zero(t, score)
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"hello", "world", "你好", "好世", "世界", "go"}, Tokenize("Hello, world! 你好世界 a Go go"))
+	assert.Equal(t, []string{"赞"}, Tokenize("赞"))
+}
+
+func TestParseTrainedDoc(t *testing.T) {
+	assert.Equal(t, trainedDoc{Label: labelSpam, Tokens: []string{"casino", "博彩"}}, parseTrainedDoc(`{"label":"spam","tokens":["casino","博彩"]}`))
+	// 旧数据只保存了分类
+	assert.Equal(t, trainedDoc{Label: labelHam}, parseTrainedDoc("ham"))
+}
+
+func TestBayesScore(t *testing.T) {
+	stats := BayesStats{
+		SpamDocs: 10,
+		HamDocs:  10,
+		Spam:     map[string]int64{"casino": 8, "博彩": 9},
+		Ham:      map[string]int64{"golang": 7, "博客": 6},
+	}
+	assert.Greater(t, stats.Score(Tokenize("online casino 博彩")), 0.9)
+	assert.Less(t, stats.Score(Tokenize("golang 博客")), 0.1)
+	// 没有训练数据覆盖的内容
+	assert.Zero(t, stats.Score(Tokenize("nothing known")))
+	// 只有一类训练数据
+	assert.Zero(t, BayesStats{SpamDocs: 1}.Score(Tokenize("casino")))
+}
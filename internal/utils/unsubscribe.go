@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gin-blog-server/internal/global"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 邮件通知类型
+const (
+	NOTIFY_REPLY   = "reply"   // 评论被回复
+	NOTIFY_COMMENT = "comment" // 文章有新评论
+	NOTIFY_MENTION = "mention" // 被 @ 提及
+)
+
+// UnsubscribeSign 计算退订链接的签名: hex(HMAC-SHA256(secret, userId + "|" + kind))
+// 退订链接需要长期有效, 因此不带过期时间
+func UnsubscribeSign(secret string, userId int, kind string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.Itoa(userId) + "|" + kind))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyUnsubscribe 校验退订链接签名
+func VerifyUnsubscribe(secret string, userId int, kind, sign string) bool {
+	return hmac.Equal([]byte(UnsubscribeSign(secret, userId, kind)), []byte(sign))
+}
+
+// GetUnsubscribeURL 生成一键退订链接
+func GetUnsubscribeURL(userId int, kind string) string {
+	query := url.Values{}
+	query.Set("user_id", strconv.Itoa(userId))
+	query.Set("type", kind)
+	query.Set("sign", UnsubscribeSign(global.GetConfig().JWT.Secret, userId, kind))
+	return GetPublicURL("/api/front/unsubscribe?" + query.Encode())
+}
+
+// GetPublicURL 根据 Server.PublicURL 生成对外访问的完整链接
+func GetPublicURL(path string) string {
+	base := strings.TrimSuffix(global.GetConfig().Server.PublicURL, "/")
+	return fmt.Sprintf("%s%s", base, path)
+}
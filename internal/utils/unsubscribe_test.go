@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnsubscribeSign(t *testing.T) {
+	sign := UnsubscribeSign("secret", 1, NOTIFY_REPLY)
+	assert.True(t, VerifyUnsubscribe("secret", 1, NOTIFY_REPLY, sign))
+	// 用户、类型、密钥任意一个不同, 签名都无效
+	assert.False(t, VerifyUnsubscribe("secret", 2, NOTIFY_REPLY, sign))
+	assert.False(t, VerifyUnsubscribe("secret", 1, NOTIFY_COMMENT, sign))
+	assert.False(t, VerifyUnsubscribe("other", 1, NOTIFY_REPLY, sign))
+	assert.False(t, VerifyUnsubscribe("secret", 1, NOTIFY_REPLY, ""))
+}
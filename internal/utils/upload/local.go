@@ -3,65 +3,100 @@ package upload
 import (
 	"errors"
 	"gin-blog-server/internal/global"
-	"gin-blog-server/internal/utils"
 	"io"
 	"log/slog"
 	"mime/multipart"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
-	"time"
 )
 
 // Local 本地文件上传
 type Local struct{}
 
-// UploadFile 文件上传到本地
-func (*Local) UploadFile(file *multipart.FileHeader) (filePath, fileName string, err error) {
-	ext := path.Ext(file.Filename)
-	name := strings.TrimSuffix(file.Filename, ext) // 读取文件名
-	name = utils.MD5(name)                         // 生成文件名MD5 hash值
+// UploadFile 文件上传到本地, 按 key 保存
+func (*Local) UploadFile(file *multipart.FileHeader, key string) (filePath string, err error) {
+	conf := global.Conf.Upload
+	if err := saveFile(file, conf.StorePath, key); err != nil {
+		return "", err
+	}
+	return conf.Path + "/" + key, nil //文件访问路径
+}
 
-	filename := name + "_" + time.Now().Format("20060102150405") + ext // 拼接生成文件名
+// UploadPrivateFile 文件上传到本地私有目录
+// 私有文件不会被静态服务暴露, 只能通过 SignURL 生成的签名链接访问
+func (*Local) UploadPrivateFile(file *multipart.FileHeader, key string) error {
+	return saveFile(file, global.Conf.Upload.PrivatePath, key)
+}
 
-	conf := global.Conf.Upload
-	mkdirErr := os.MkdirAll(conf.StorePath, os.ModePerm) // 创建存储路径
+// DeletePrivateFile 从本地私有目录删除文件
+func (*Local) DeletePrivateFile(key string) error {
+	p, err := PrivateFilePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return errors.New("本地文件删除失败, err:" + err.Error())
+	}
+	return nil
+}
+
+// PrivateFilePath 根据文件 key 获取私有文件的存储路径
+// key 只能是单纯的文件名, 防止通过 "../" 访问存储目录以外的文件
+func PrivateFilePath(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", errors.New("invalid file key")
+	}
+	return filepath.Join(global.GetConfig().Upload.PrivatePath, key), nil
+}
+
+// saveFile 将文件以 key 为文件名保存到 dir 目录中
+// 内容寻址存储下相同 key 的文件内容一定相同, 文件已存在时直接跳过写入
+func saveFile(file *multipart.FileHeader, dir, key string) error {
+	mkdirErr := os.MkdirAll(dir, os.ModePerm) // 创建存储路径
 	if mkdirErr != nil {
 		slog.Error("function os.MkdirAll() Filed", slog.Any("err", mkdirErr.Error()))
-		return "", "", errors.New("function os.MkdirAll() Filed, err:" + mkdirErr.Error())
+		return errors.New("function os.MkdirAll() Filed, err:" + mkdirErr.Error())
 	}
 
-	storePath := conf.StorePath + "/" + filename //文件存储路径
-	filePath = conf.Path + "/" + filename        //文件访问路径
+	storePath := dir + "/" + key //文件存储路径
+	if _, err := os.Stat(storePath); err == nil {
+		return nil
+	}
 
 	f, openError := file.Open() // 读取文件
 	if openError != nil {
 		slog.Error("function file.Open() Filed", slog.String("err", openError.Error()))
-		return "", "", errors.New("function file.Open() Filed, err:" + openError.Error())
+		return errors.New("function file.Open() Filed, err:" + openError.Error())
 	}
 	defer f.Close()
 
-	// 创建文件的保存位置，即文件写入位置
-	out, createErr := os.Create(storePath)
+	// 先写入临时文件再重命名, 避免写入中途失败留下不完整的文件
+	tmp, createErr := os.CreateTemp(dir, key+".*.tmp")
 	if createErr != nil {
-		slog.Error("function os.Create() Filed", slog.String("err", createErr.Error()))
-		return "", "", errors.New("function os.Create() Filed, err:" + createErr.Error())
+		slog.Error("function os.CreateTemp() Filed", slog.String("err", createErr.Error()))
+		return errors.New("function os.CreateTemp() Filed, err:" + createErr.Error())
 	}
-	defer out.Close()
+	defer os.Remove(tmp.Name())
 
-	_, copyErr := io.Copy(out, f) //拷贝文件
+	_, copyErr := io.Copy(tmp, f) //拷贝文件
+	tmp.Close()
 	if copyErr != nil {
 		slog.Error("function io.Copy() Filed", slog.String("err", copyErr.Error()))
-		return "", "", errors.New("function io.Copy() Filed, err:" + copyErr.Error())
+		return errors.New("function io.Copy() Filed, err:" + copyErr.Error())
 	}
-	return filePath, filename, nil
+	if err := os.Rename(tmp.Name(), storePath); err != nil {
+		slog.Error("function os.Rename() Filed", slog.String("err", err.Error()))
+		return errors.New("function os.Rename() Filed, err:" + err.Error())
+	}
+	return nil
 }
 
 // DeleteFile 从本地删除文件
 func (*Local) DeleteFile(key string) error {
 	p := global.GetConfig().Upload.StorePath + "/" + key
 	if strings.Contains(p, global.GetConfig().Upload.StorePath) {
-		if err := os.Remove(p); err != nil {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
 			return errors.New("本地文件删除失败, err:" + err.Error())
 		}
 	}
@@ -1,13 +1,19 @@
 package upload
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"gin-blog-server/internal/global"
+	"io"
 	"mime/multipart"
+	"path"
+	"strings"
 )
 
 // OSS 对象存储接口
+// 文件按内容寻址存储: key 由文件内容的 SHA-256 与扩展名组成, 相同内容的文件 key 相同
 type OSS interface {
-	UploadFile(file *multipart.FileHeader) (string, string, error)
+	UploadFile(file *multipart.FileHeader, key string) (string, error)
 	DeleteFile(key string) error
 }
 
@@ -22,3 +28,23 @@ func NewOSS() OSS {
 		return &Local{}
 	}
 }
+
+// Hash 计算文件内容的 SHA-256
+func Hash(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ContentKey 根据内容 hash 和原始文件名生成存储 key, 例如 "9f86d0...0a08.png"
+func ContentKey(hash, filename string) string {
+	return hash + strings.ToLower(path.Ext(filename))
+}
@@ -0,0 +1,40 @@
+package upload
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFileHeader 构造一个 multipart 文件
+func newFileHeader(t *testing.T, filename string, content []byte) *multipart.FileHeader {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, err := w.CreateFormFile("file", filename)
+	assert.Nil(t, err)
+	part.Write(content)
+	w.Close()
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	_, fh, err := req.FormFile("file")
+	assert.Nil(t, err)
+	return fh
+}
+
+func TestContentKey(t *testing.T) {
+	a, err := Hash(newFileHeader(t, "a.PNG", []byte("same content")))
+	assert.Nil(t, err)
+	b, err := Hash(newFileHeader(t, "b.png", []byte("same content")))
+	assert.Nil(t, err)
+	c, err := Hash(newFileHeader(t, "a.PNG", []byte("other content")))
+	assert.Nil(t, err)
+
+	// 内容相同则 hash 相同, 与文件名无关
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.Len(t, a, 64)
+	assert.Equal(t, a+".png", ContentKey(a, "a.PNG"))
+}
@@ -3,40 +3,36 @@ package upload
 import (
 	"context"
 	"errors"
-	"fmt"
 	"gin-blog-server/internal/global"
-	"gin-blog-server/internal/utils"
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
 	"mime/multipart"
-	"path"
-	"time"
 )
 
 type Qiniu struct{}
 
-func (*Qiniu) UploadFile(file *multipart.FileHeader) (filePath, fileName string, err error) {
+// UploadFile 上传文件到七牛云, 按 key 保存
+func (*Qiniu) UploadFile(file *multipart.FileHeader, key string) (filePath string, err error) {
 	putPolicy := storage.PutPolicy{Scope: global.GetConfig().Qiniu.Bucket}
 	mac := qbox.NewMac(global.GetConfig().Qiniu.AccessKey, global.GetConfig().Qiniu.SecretKey)
 	upToken := putPolicy.UploadToken(mac)
 	formUploader := storage.NewFormUploader(qiniuConfig())
 
 	ret := storage.PutRet{}
-	putExtra := storage.PutExtra{Params: map[string]string{"x:name": "github logo"}}
+	putExtra := storage.PutExtra{Params: map[string]string{"x:name": file.Filename}}
 
 	f, openError := file.Open()
 	if openError != nil {
-		return "", "", errors.New("function file.Open() Filed, err:" + openError.Error())
+		return "", errors.New("function file.Open() Filed, err:" + openError.Error())
 	}
 	defer f.Close()
 
-	// 文件名格式 建议保证唯一性
-	fileKey := fmt.Sprintf("%d%s%s", time.Now().Unix(), utils.MD5(file.Filename), path.Ext(file.Filename))
-	putErr := formUploader.Put(context.Background(), &ret, upToken, fileKey, f, file.Size, &putExtra)
+	// 文件名使用内容 hash, 相同内容的文件 key 相同
+	putErr := formUploader.Put(context.Background(), &ret, upToken, key, f, file.Size, &putExtra)
 	if putErr != nil {
-		return "", "", errors.New("function formUploader.Put() Filed, err:" + putErr.Error())
+		return "", errors.New("function formUploader.Put() Filed, err:" + putErr.Error())
 	}
-	return global.GetConfig().Qiniu.ImgPath + "/" + ret.Key, ret.Key, nil
+	return global.GetConfig().Qiniu.ImgPath + "/" + ret.Key, nil
 }
 
 func (*Qiniu) DeleteFile(key string) error {
@@ -0,0 +1,80 @@
+package upload
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gin-blog-server/internal/global"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+私有文件签名链接设计:
+
+私有文件不通过 r.Static 暴露, 只能通过带签名的链接访问:
+  - 链接格式: /api/file/private/{key}?expires={unix 时间戳}&sign={签名}
+  - 签名算法: sign = hex(HMAC-SHA256(secret, key + "|" + expires))
+  - 签名同时绑定文件 key 与过期时间, 修改其中任何一个都会导致签名失效
+  - secret 优先使用 Upload.SignKey, 为空时使用 JWT.Secret
+*/
+
+// PRIVATE_FILE_URL 私有文件访问路径前缀
+const PRIVATE_FILE_URL = "/api/file/private/"
+
+var (
+	ErrSignInvalid = errors.New("文件签名无效")
+	ErrSignExpired = errors.New("文件签名已过期")
+)
+
+// Sign 计算文件 key 在 expires 时间之前有效的签名
+func Sign(secret, key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key + "|" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySign 校验签名是否有效, 以及是否已经过期
+func VerifySign(secret, key, expires, sign string, now time.Time) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || key == "" || sign == "" {
+		return ErrSignInvalid
+	}
+	// 使用 hmac.Equal 进行常量时间比较, 防止时序攻击
+	if !hmac.Equal([]byte(Sign(secret, key, exp)), []byte(sign)) {
+		return ErrSignInvalid
+	}
+	if now.Unix() > exp {
+		return ErrSignExpired
+	}
+	return nil
+}
+
+// SignSecret 获取签名密钥: 优先使用专用密钥, 否则使用 JWT 密钥
+func SignSecret() string {
+	conf := global.GetConfig()
+	if conf.Upload.SignKey != "" {
+		return conf.Upload.SignKey
+	}
+	return conf.JWT.Secret
+}
+
+// SignExpire 获取签名链接有效期, 未配置时默认 30 分钟
+func SignExpire() time.Duration {
+	expire := global.GetConfig().Upload.SignExpire
+	if expire <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(expire) * time.Second
+}
+
+// SignURL 生成私有文件的签名访问链接, 返回链接及其过期时间
+func SignURL(key string) (string, time.Time) {
+	expiresAt := time.Now().Add(SignExpire())
+	expires := expiresAt.Unix()
+	sign := Sign(SignSecret(), key, expires)
+	return fmt.Sprintf("%s%s?expires=%d&sign=%s", PRIVATE_FILE_URL, url.PathEscape(key), expires, sign), expiresAt
+}
@@ -0,0 +1,26 @@
+package upload
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := "secret"
+	key := "a.png"
+	now := time.Now()
+	expires := now.Add(time.Minute).Unix()
+	sign := Sign(secret, key, expires)
+	exp := strconv.FormatInt(expires, 10)
+
+	assert.Nil(t, VerifySign(secret, key, exp, sign, now))
+	// 篡改 key / 过期时间 / 密钥 都会导致签名失效
+	assert.ErrorIs(t, VerifySign(secret, "b.png", exp, sign, now), ErrSignInvalid)
+	assert.ErrorIs(t, VerifySign(secret, key, strconv.FormatInt(expires+60, 10), sign, now), ErrSignInvalid)
+	assert.ErrorIs(t, VerifySign("other", key, exp, sign, now), ErrSignInvalid)
+	assert.ErrorIs(t, VerifySign(secret, key, "abc", sign, now), ErrSignInvalid)
+	// 过期
+	assert.ErrorIs(t, VerifySign(secret, key, exp, sign, now.Add(2*time.Minute)), ErrSignExpired)
+}
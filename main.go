@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	_ "gin-blog-server/docs"
 	ginblog "gin-blog-server/internal"
 	"gin-blog-server/internal/global"
 	"gin-blog-server/internal/middleware"
+	"gin-blog-server/internal/utils"
 	"github.com/gin-gonic/gin"
 	"log"
 	"strings"
@@ -20,11 +22,22 @@ func main() {
 	_ = ginblog.InitLogger(conf)
 	db := ginblog.InitDatabase(conf)
 	rdb := ginblog.InitRedis(conf)
+	ginblog.InitSensitive(db)
+	ginblog.InitReactions(db, rdb)
+	ginblog.InitCommentLikes(db, rdb)
+	ginblog.InitDanmaku(rdb)
+	ginblog.InitAccountDeletion(db, rdb)
+
+	// 后台发送邮件队列中的邮件
+	go utils.RunEmailWorker(context.Background(), rdb)
 
 	//初始化gin服务
 	gin.SetMode(conf.Server.Mode)
 	r := gin.New()
-	r.SetTrustedProxies([]string{"*"}) //设置 Gin 框架信任所有的代理（Proxy）
+	// 只信任配置的反向代理, 否则客户端可以伪造 X-Forwarded-For 绕过 IP 封禁
+	if err := r.SetTrustedProxies(conf.Server.TrustedProxies); err != nil {
+		log.Fatalf("信任的代理配置错误: %v", err)
+	}
 
 	// 开发模式使用 gin 自带的日志和恢复中间件, 生产模式使用自定义的中间件
 	if conf.Server.Mode == "debug" {
@@ -36,9 +49,10 @@ func main() {
 	r.Use(middleware.WithGormDB(db), middleware.WithRedisDB(rdb), middleware.CORS(), middleware.WithCookieStore(conf.Session.Name, conf.Session.Salt))
 	ginblog.RegisterHandlers(r)
 
-	//使用本地文件上传服务
+	//使用本地文件上传服务, 公开文件开启防盗链校验; 私有文件不做静态暴露, 通过签名链接访问
 	if conf.Upload.OssType == "local" {
-		r.Static(conf.Upload.Path, conf.Upload.StorePath)
+		r.Group(conf.Upload.Path, middleware.RefererCheck(conf.Upload.Referers)).
+			Static("/", conf.Upload.StorePath)
 	}
 
 	serverAddr := conf.Server.Port